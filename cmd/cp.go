@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+)
+
+// runCopy uploads a host file or directory into a container:
+//
+//	stackr cp [-f] [-q] <host-path> <container>:<path>
+func runCopy(client *docker.Client, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("cp", flag.ContinueOnError)
+	force := fs.Bool("f", false, "overwrite the destination if it exists")
+	quiet := fs.Bool("q", false, "don't print progress")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: stackr cp [-f] [-q] <host-path> <container>:<path>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected a source and a destination")
+	}
+
+	src := fs.Arg(0)
+	ctr, dest, ok := strings.Cut(fs.Arg(1), ":")
+	if !ok || ctr == "" || !path.IsAbs(dest) {
+		return fmt.Errorf("invalid destination %q: expected <container>:/absolute/path", fs.Arg(1))
+	}
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	dir, name, exists, err := client.UploadTarget(ctx, ctr, src, dest)
+	if err != nil {
+		return err
+	}
+	if exists && !*force {
+		return fmt.Errorf("%s already exists in %s, use -f to overwrite", path.Join(dir, name), ctr)
+	}
+
+	var progress func(sent, total int64)
+	if !*quiet {
+		last := ""
+		progress = func(sent, total int64) {
+			// Only redraw when the rounded figure changes.
+			if line := formatProgress(sent, total); line != last {
+				fmt.Fprintf(os.Stderr, "\r%s", line)
+				last = line
+			}
+		}
+	}
+	if err := client.Upload(ctx, ctr, src, dir, name, progress); err != nil {
+		return err
+	}
+	if !*quiet {
+		fmt.Fprintf(os.Stderr, "\rcopied %s to %s:%s\n", src, ctr, path.Join(dir, name))
+	}
+	return nil
+}
+
+func formatProgress(sent, total int64) string {
+	if total <= 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%3d%%  %s / %s", sent*100/total, format.Bytes(uint64(sent)), format.Bytes(uint64(total)))
+}
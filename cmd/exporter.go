@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/exporter"
+)
+
+// runExporter serves Prometheus metrics until killed:
+//
+//	stackr exporter [-listen :9338]
+func runExporter(client *docker.Client, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("exporter", flag.ContinueOnError)
+	listen := fs.String("listen", ":9338", "address to serve /metrics on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter.New(client))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "stackr exporter, metrics are at /metrics")
+	})
+
+	fmt.Fprintf(os.Stderr, "serving metrics on %s/metrics\n", *listen)
+	srv := &http.Server{
+		Addr:    *listen,
+		Handler: mux,
+		// Scrapes are small GETs; a client that takes longer than this to
+		// send its headers is only holding a connection open.
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
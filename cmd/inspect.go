@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/view"
+)
+
+// runInspect shows the details of one or more containers:
+//
+//	stackr inspect [-format yaml|json|table|wide|csv|<template>] <container>...
+func runInspect(client *docker.Client, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	format := fs.String("format", "yaml", view.FormatUsage)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: stackr inspect [-format <format>] <container>...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("expected at least one container")
+	}
+
+	var items []view.Detail
+	for _, name := range fs.Args() {
+		ins, err := client.Inspect(context.Background(), name)
+		if err != nil {
+			return err
+		}
+		items = append(items, view.FromInspect(ins))
+	}
+	return view.Write(os.Stdout, *format, "ContainerDetail", items)
+}
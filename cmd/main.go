@@ -4,11 +4,30 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
 	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
 	"github.com/aogirikarma/mini-stackr-cli/pkg/tui"
 )
 
+// commands are the non-interactive entry points, run as stackr <name> ...
+// Without a command the TUI starts.
+var commands = map[string]func(client *docker.Client, cfg config.Config, args []string) error{
+	"cp":       runCopy,
+	"exporter": runExporter,
+	"inspect":  runInspect,
+	"prune":    runPrune,
+	"ps":       runPs,
+	"stack":    runStack,
+	"watch":    runWatch,
+}
+
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
 	client, err := docker.NewClient()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to connect to docker: %v\n", err)
@@ -16,8 +35,32 @@ func main() {
 	}
 	defer client.Close()
 
-	if err := tui.Run(client); err != nil {
+	source := "tui"
+	if len(os.Args) > 1 {
+		source = "cli"
+	}
+	auditLog, err := config.AuditLog(cfg, source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	client.SetAuditLog(auditLog)
+
+	if len(os.Args) > 1 {
+		run, ok := commands[os.Args[1]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+			os.Exit(2)
+		}
+		if err := run(client, cfg, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := tui.Run(client, cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/view"
+)
+
+const pruneUsage = "usage: stackr prune [-dry-run] [-containers] [-older-than <duration>] [-images dangling|unused] [-volumes anonymous|all] [-networks] [-build-cache] [-format <format>]"
+
+// runPrune removes unused objects, or only lists them with -dry-run:
+//
+//	stackr prune [-dry-run] [-containers] [-older-than 24h] [-images dangling|unused]
+//	             [-volumes anonymous|all] [-networks] [-build-cache] [-format <format>]
+//
+// Without any selection it prunes what docker system prune does: stopped
+// containers, dangling images, unused networks and build cache.
+func runPrune(client *docker.Client, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only list what would be removed")
+	var opts docker.PruneOptions
+	fs.BoolVar(&opts.Containers, "containers", false, "remove stopped containers")
+	fs.DurationVar(&opts.OlderThan, "older-than", 0, "only remove containers created at least this long ago")
+	fs.StringVar(&opts.Images, "images", "", "remove dangling or all unused images")
+	fs.StringVar(&opts.Volumes, "volumes", "", "remove anonymous or all unused volumes")
+	fs.BoolVar(&opts.Networks, "networks", false, "remove unused networks")
+	fs.BoolVar(&opts.BuildCache, "build-cache", false, "remove unused build cache")
+	outFormat := fs.String("format", "table", view.FormatUsage)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), pruneUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	selected := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "containers", "older-than", "images", "volumes", "networks", "build-cache":
+			selected = true
+		}
+	})
+	if !selected {
+		opts = docker.PruneOptions{Containers: true, Images: docker.PruneDangling, Networks: true, BuildCache: true}
+	}
+	if opts.OlderThan > 0 {
+		opts.Containers = true
+	}
+	switch opts.Images {
+	case "", docker.PruneDangling, docker.PruneUnused:
+	default:
+		return fmt.Errorf("invalid -images %q: expected dangling or unused", opts.Images)
+	}
+	switch opts.Volumes {
+	case "", docker.PruneAnonymous, docker.PruneAll:
+	default:
+		return fmt.Errorf("invalid -volumes %q: expected anonymous or all", opts.Volumes)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	items, err := client.PlanPrune(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if !*dryRun {
+		items = client.ExecutePrune(ctx, items, nil)
+	}
+
+	out := make([]view.PruneItem, len(items))
+	var size int64
+	failed := 0
+	for i, item := range items {
+		out[i] = view.FromPruneItem(item, !*dryRun)
+		if item.Err != nil {
+			failed++
+		} else {
+			size += item.Size
+		}
+	}
+	if err := view.Write(os.Stdout, *outFormat, "PruneReport", out); err != nil {
+		return err
+	}
+
+	switch {
+	case len(items) == 0:
+		fmt.Fprintln(os.Stderr, "nothing to prune")
+	case *dryRun:
+		fmt.Fprintf(os.Stderr, "would remove %d objects, freeing %s\n", len(items), format.Bytes(uint64(size)))
+	default:
+		fmt.Fprintf(os.Stderr, "removed %d objects, freed %s\n", len(items)-failed, format.Bytes(uint64(size)))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d objects could not be removed", failed, len(items))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/view"
+	"github.com/moby/moby/api/types/container"
+)
+
+// runPs lists containers:
+//
+//	stackr ps [-a] [-format table|wide|json|yaml|csv|<template>]
+func runPs(client *docker.Client, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("ps", flag.ContinueOnError)
+	all := fs.Bool("a", false, "show stopped containers too")
+	format := fs.String("format", "table", view.FormatUsage)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: stackr ps [-a] [-format <format>]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	containers, err := client.ListContainers(context.Background())
+	if err != nil {
+		return err
+	}
+	items := []view.Container{}
+	for _, c := range containers {
+		if *all || c.State == container.StateRunning {
+			items = append(items, view.FromSummary(c))
+		}
+	}
+	return view.Write(os.Stdout, *format, "ContainerList", items)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/stack"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/view"
+)
+
+const stackUsage = "usage: stackr stack <up|down|status|diff> [-f <file>] [-profile <name>]... [flags]"
+
+// runStack manages the stack described by a stack file or a Compose file:
+//
+//	stackr stack up     [-f file] [-profile name]... [-remove-orphans]
+//	stackr stack down   [-f file] [-profile name]... [-v]
+//	stackr stack status [-f file] [-profile name]... [-format <format>]
+//	stackr stack diff   [-f file] [-profile name]...
+func runStack(client *docker.Client, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(stackUsage)
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("stack "+sub, flag.ContinueOnError)
+	file := fs.String("f", "", "stack or Compose file (default stackr.yaml, then compose.yaml, in the current directory)")
+	var profiles stringList
+	fs.Var(&profiles, "profile", "enable a Compose profile, can be repeated")
+	var volumes, removeOrphans *bool
+	var format *string
+	switch sub {
+	case "up":
+		removeOrphans = fs.Bool("remove-orphans", false, "remove containers of services no longer in the file")
+	case "down":
+		volumes = fs.Bool("v", false, "remove the stack's volumes too")
+	case "status":
+		format = fs.String("format", "table", view.FormatUsage)
+	case "diff":
+	default:
+		return fmt.Errorf("unknown stack command %q\n%s", sub, stackUsage)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	st, err := loadStack(*file, profiles)
+	if err != nil {
+		return err
+	}
+	for _, w := range st.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	progress := func(line string) { fmt.Fprintln(os.Stderr, line) }
+
+	switch sub {
+	case "up":
+		notes, err := config.NotesStore()
+		if err != nil {
+			return err
+		}
+		if err := stack.Up(ctx, client, st, stack.UpOptions{Notes: notes, RemoveOrphans: *removeOrphans}, progress); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "stack %s is up\n", st.Name)
+		return nil
+	case "down":
+		return stack.Down(ctx, client, st, *volumes, progress)
+	}
+
+	containers, err := client.ListContainers(ctx)
+	if err != nil {
+		return err
+	}
+	changes, err := stack.Diff(st, containers, stack.Inspector(ctx, client))
+	if err != nil {
+		return err
+	}
+
+	if sub == "status" {
+		items := make([]view.StackService, len(changes))
+		for i, ch := range changes {
+			items[i] = view.FromChange(st.Name, ch)
+		}
+		return view.Write(os.Stdout, *format, "StackStatus", items)
+	}
+
+	if stack.Drifted(changes) == 0 {
+		fmt.Printf("stack %s is up to date\n", st.Name)
+		return nil
+	}
+	for _, ch := range changes {
+		if ch.Action == stack.ActionNone {
+			continue
+		}
+		fmt.Printf("%s %-8s %s (%s)\n", diffMarks[ch.Action], ch.Action, ch.Service, ch.Reason)
+	}
+	return nil
+}
+
+var diffMarks = map[stack.Action]string{
+	stack.ActionCreate:   "+",
+	stack.ActionRecreate: "~",
+	stack.ActionStart:    ">",
+	stack.ActionRemove:   "-",
+}
+
+func loadStack(file string, profiles []string) (*stack.Stack, error) {
+	if file == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		if file, err = stack.FindFile(wd); err != nil {
+			return nil, err
+		}
+	}
+	return stack.Load(file, profiles...)
+}
+
+// stringList is a flag that can be given several times.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
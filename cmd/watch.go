@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/alert"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/history"
+)
+
+// runWatch evaluates the configured alert rules and records stats history
+// without the TUI, printing alerts as they fire:
+//
+//	stackr watch
+func runWatch(client *docker.Client, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if len(cfg.Alerts.Rules) == 0 && cfg.History.Disabled {
+		p, _ := config.Path()
+		return errors.New("nothing to watch, no alert rules and history disabled in " + p)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !cfg.History.Disabled {
+		store, err := config.HistoryStore(cfg)
+		if err != nil {
+			return err
+		}
+		go history.NewRecorder(client, store).Run(ctx)
+		fmt.Fprintln(os.Stderr, "recording stats history")
+	}
+	if len(cfg.Alerts.Rules) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	w := alert.NewWatcher(client, cfg.Alerts)
+	w.OnNotifyError(func(err error) {
+		fmt.Fprintf(os.Stderr, "%s alert notification failed: %v\n", time.Now().Format("2006-01-02 15:04:05"), err)
+	})
+	go w.Run(ctx)
+
+	fmt.Fprintf(os.Stderr, "watching %d rules\n", len(cfg.Alerts.Rules))
+	for a := range w.Alerts() {
+		fmt.Printf("%s %s\n", a.Time.Format("2006-01-02 15:04:05"), a)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Package alert watches containers and raises notifications when user
+// defined rules match.
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+)
+
+// Kind selects what a rule checks.
+type Kind string
+
+const (
+	// KindExit fires when a container exits with a non-zero code without
+	// having been stopped or killed by a user.
+	KindExit Kind = "exit"
+	// KindUnhealthy fires when a health check turns the container unhealthy.
+	KindUnhealthy Kind = "unhealthy"
+	// KindRestarts fires when a container starts more than Count times
+	// within Window.
+	KindRestarts Kind = "restarts"
+	// KindMemory fires when memory usage goes above Percent of the limit.
+	KindMemory Kind = "memory"
+)
+
+const (
+	defaultInterval = 30 * time.Second
+	defaultCooldown = 10 * time.Minute
+)
+
+// Config is the alerts section of the config file:
+//
+//	{
+//	  "rules": [
+//	    {"name": "crashed", "when": "exit"},
+//	    {"name": "flapping", "when": "restarts", "count": 3, "window": "10m"},
+//	    {"name": "db-memory", "when": "memory", "containers": "db-*", "percent": 90}
+//	  ],
+//	  "webhook": "https://hooks.example.com/stackr",
+//	  "command": "notify-send \"$STACKR_ALERT_RULE\" \"$STACKR_ALERT_MESSAGE\""
+//	}
+type Config struct {
+	Rules []Rule `json:"rules"`
+	// Webhook receives every alert as a JSON POST.
+	Webhook string `json:"webhook,omitempty"`
+	// Command is run through sh -c for every alert, with the alert in
+	// STACKR_ALERT_* environment variables.
+	Command string `json:"command,omitempty"`
+	// Interval is how often memory rules sample stats. Defaults to 30s.
+	Interval Duration `json:"interval,omitempty"`
+	// Cooldown silences a rule for a container after it fired. Defaults
+	// to 10m.
+	Cooldown Duration `json:"cooldown,omitempty"`
+}
+
+// Rule is a single alert condition.
+type Rule struct {
+	Name string `json:"name"`
+	When Kind   `json:"when"`
+	// Containers is a glob matched against container names. Empty
+	// matches every container.
+	Containers string   `json:"containers,omitempty"`
+	Count      int      `json:"count,omitempty"`
+	Window     Duration `json:"window,omitempty"`
+	Percent    float64  `json:"percent,omitempty"`
+}
+
+func (c Config) Validate() error {
+	for i, r := range c.Rules {
+		if err := r.validate(); err != nil {
+			name := r.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return fmt.Errorf("alert rule %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (r Rule) validate() error {
+	if _, err := path.Match(r.Containers, ""); err != nil {
+		return fmt.Errorf("invalid containers pattern: %w", err)
+	}
+	switch r.When {
+	case KindExit, KindUnhealthy:
+	case KindRestarts:
+		if r.Count < 1 || r.Window <= 0 {
+			return fmt.Errorf("restarts needs a positive count and window")
+		}
+	case KindMemory:
+		if r.Percent <= 0 || r.Percent > 100 {
+			return fmt.Errorf("memory percent must be between 0 and 100")
+		}
+	default:
+		return fmt.Errorf("unknown condition %q, use exit, unhealthy, restarts or memory", r.When)
+	}
+	return nil
+}
+
+// label is how the rule is referred to in alerts.
+func (r Rule) label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return string(r.When)
+}
+
+func (r Rule) matches(name string) bool {
+	if r.Containers == "" {
+		return true
+	}
+	ok, _ := path.Match(r.Containers, name)
+	return ok
+}
+
+func (c Config) interval() time.Duration {
+	if c.Interval > 0 {
+		return time.Duration(c.Interval)
+	}
+	return defaultInterval
+}
+
+func (c Config) cooldown() time.Duration {
+	if c.Cooldown > 0 {
+		return time.Duration(c.Cooldown)
+	}
+	return defaultCooldown
+}
+
+// Duration is a time.Duration written as a string such as "10m" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10m\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,67 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// notifyTimeout bounds a single webhook call or command hook.
+const notifyTimeout = 30 * time.Second
+
+// postWebhook and runCommand return the notification for a, to be sent
+// off the watcher's goroutine. Failures are returned rather than printed:
+// only stackr watch has somewhere to print them, the TUI owns the terminal.
+
+func postWebhook(url string, a Alert) func() error {
+	return func() error {
+		body, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("webhook: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("webhook: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook: %s answered %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+func runCommand(command string, a Alert) func() error {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Env = append(os.Environ(),
+			"STACKR_ALERT_RULE="+a.Rule,
+			"STACKR_ALERT_CONTAINER="+a.Container,
+			"STACKR_ALERT_ID="+a.ID,
+			"STACKR_ALERT_MESSAGE="+a.Message,
+			"STACKR_ALERT_TIME="+a.Time.Format(time.RFC3339),
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				return fmt.Errorf("command: %w: %s", err, msg)
+			}
+			return fmt.Errorf("command: %w", err)
+		}
+		return nil
+	}
+}
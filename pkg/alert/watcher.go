@@ -0,0 +1,307 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/stats"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+)
+
+const (
+	// userStopWindow is how long after a kill event a non-zero exit is
+	// attributed to the user stopping the container rather than a crash.
+	userStopWindow = 30 * time.Second
+	// reconnectDelay is the wait before resubscribing to a dropped event
+	// stream.
+	reconnectDelay = 5 * time.Second
+)
+
+// Alert is a fired rule.
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Container string    `json:"container"`
+	ID        string    `json:"id"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("[%s] %s: %s", a.Rule, a.Container, a.Message)
+}
+
+// Watcher evaluates rules against the daemon's event stream and periodic
+// stats samples.
+type Watcher struct {
+	client *docker.Client
+	cfg    Config
+	alerts chan Alert
+	// notifyErr, if set, is told about failed webhook calls and commands.
+	notifyErr func(error)
+
+	mu     sync.Mutex
+	fired  map[string]time.Time   // rule/container -> last alert
+	starts map[string][]time.Time // container -> recent starts
+	killed map[string]time.Time   // container -> last kill or stop request
+}
+
+func NewWatcher(client *docker.Client, cfg Config) *Watcher {
+	return &Watcher{
+		client: client,
+		cfg:    cfg,
+		alerts: make(chan Alert, 16),
+		fired:  map[string]time.Time{},
+		starts: map[string][]time.Time{},
+		killed: map[string]time.Time{},
+	}
+}
+
+// Alerts delivers fired alerts. It is closed when Run returns. Alerts are
+// dropped rather than blocking the watcher when nobody reads them.
+func (w *Watcher) Alerts() <-chan Alert {
+	return w.alerts
+}
+
+// OnNotifyError has f told about webhook calls and commands that fail. It
+// must be set before Run and may be called from several goroutines.
+func (w *Watcher) OnNotifyError(f func(error)) {
+	w.notifyErr = f
+}
+
+// Run watches until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	defer close(w.alerts)
+
+	var wg sync.WaitGroup
+	if w.has(KindMemory) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.pollStats(ctx)
+		}()
+	}
+	if w.has(KindExit) || w.has(KindUnhealthy) || w.has(KindRestarts) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.watchEvents(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Watcher) has(kind Kind) bool {
+	for _, r := range w.cfg.Rules {
+		if r.When == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) watchEvents(ctx context.Context) {
+	for {
+		messages, errs := w.client.Events(ctx)
+	stream:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-messages:
+				w.handleEvent(msg)
+			case <-errs:
+				break stream
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (w *Watcher) handleEvent(msg events.Message) {
+	id := msg.Actor.ID
+	name := msg.Actor.Attributes["name"]
+	now := time.Unix(0, msg.TimeNano)
+
+	switch msg.Action {
+	case events.ActionKill, events.ActionStop:
+		w.mu.Lock()
+		w.killed[id] = now
+		w.mu.Unlock()
+
+	case events.ActionDie:
+		code := msg.Actor.Attributes["exitCode"]
+		if code == "" || code == "0" || w.stoppedByUser(id, now) {
+			return
+		}
+		w.check(KindExit, id, name, func(Rule) string {
+			return "exited with code " + code
+		})
+
+	case events.ActionHealthStatusUnhealthy:
+		w.check(KindUnhealthy, id, name, func(Rule) string {
+			return "health check is failing"
+		})
+
+	case events.ActionStart:
+		w.mu.Lock()
+		w.starts[id] = append(w.starts[id], now)
+		starts := w.starts[id]
+		w.mu.Unlock()
+
+		w.check(KindRestarts, id, name, func(r Rule) string {
+			n := countSince(starts, now.Add(-time.Duration(r.Window)))
+			if n <= r.Count {
+				return ""
+			}
+			return fmt.Sprintf("started %d times in %s", n, time.Duration(r.Window))
+		})
+		w.pruneStarts(id, now)
+
+	case events.ActionDestroy:
+		w.mu.Lock()
+		delete(w.starts, id)
+		delete(w.killed, id)
+		w.mu.Unlock()
+	}
+}
+
+func (w *Watcher) stoppedByUser(id string, at time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	t, ok := w.killed[id]
+	delete(w.killed, id)
+	return ok && at.Sub(t) < userStopWindow
+}
+
+// pruneStarts drops starts older than the longest restarts window.
+func (w *Watcher) pruneStarts(id string, now time.Time) {
+	var longest time.Duration
+	for _, r := range w.cfg.Rules {
+		if r.When == KindRestarts {
+			longest = max(longest, time.Duration(r.Window))
+		}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	starts := w.starts[id]
+	i := 0
+	for i < len(starts) && starts[i].Before(now.Add(-longest)) {
+		i++
+	}
+	w.starts[id] = starts[i:]
+}
+
+func countSince(times []time.Time, since time.Time) int {
+	n := 0
+	for _, t := range times {
+		if !t.Before(since) {
+			n++
+		}
+	}
+	return n
+}
+
+func (w *Watcher) pollStats(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.interval())
+	defer ticker.Stop()
+	for {
+		w.sampleMemory(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) sampleMemory(ctx context.Context) {
+	containers, err := w.client.ListContainers(ctx)
+	if err != nil {
+		return
+	}
+	for _, c := range containers {
+		if c.State != container.StateRunning || len(c.Names) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(c.Names[0], "/")
+		if !w.watched(KindMemory, name) {
+			continue
+		}
+		s, err := w.client.Stats(ctx, c.ID)
+		if err != nil || s.MemoryStats.Limit == 0 {
+			continue
+		}
+		percent := stats.MemoryPercent(s)
+		w.check(KindMemory, c.ID, name, func(r Rule) string {
+			if percent <= r.Percent {
+				return ""
+			}
+			return fmt.Sprintf("memory at %.0f%% of limit (threshold %.0f%%)", percent, r.Percent)
+		})
+	}
+}
+
+// watched reports whether any rule of kind applies to the container.
+func (w *Watcher) watched(kind Kind, name string) bool {
+	for _, r := range w.cfg.Rules {
+		if r.When == kind && r.matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// check runs every rule of kind matching the container. message returns
+// the alert text, or "" when the rule's condition doesn't hold.
+func (w *Watcher) check(kind Kind, id, name string, message func(Rule) string) {
+	for _, r := range w.cfg.Rules {
+		if r.When != kind || !r.matches(name) {
+			continue
+		}
+		text := message(r)
+		if text == "" || !w.cooledDown(r.label()+"/"+id) {
+			continue
+		}
+		w.fire(Alert{Rule: r.label(), Container: name, ID: id, Message: text, Time: time.Now()})
+	}
+}
+
+func (w *Watcher) cooledDown(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	if last, ok := w.fired[key]; ok && now.Sub(last) < w.cfg.cooldown() {
+		return false
+	}
+	w.fired[key] = now
+	return true
+}
+
+func (w *Watcher) fire(a Alert) {
+	select {
+	case w.alerts <- a:
+	default:
+	}
+	if w.cfg.Webhook != "" {
+		go w.notify(postWebhook(w.cfg.Webhook, a))
+	}
+	if w.cfg.Command != "" {
+		go w.notify(runCommand(w.cfg.Command, a))
+	}
+}
+
+func (w *Watcher) notify(send func() error) {
+	if err := send(); err != nil && w.notifyErr != nil {
+		w.notifyErr(err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package audit appends a JSONL record of every change made through stackr.
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time        time.Time      `json:"time"`
+	User        string         `json:"user"`
+	Host        string         `json:"host"`
+	Source      string         `json:"source"`
+	ContainerID string         `json:"container_id,omitempty"`
+	Container   string         `json:"container,omitempty"`
+	Action      string         `json:"action"`
+	Params      map[string]any `json:"params,omitempty"`
+	Result      string         `json:"result"`
+	Error       string         `json:"error,omitempty"`
+}
+
+const (
+	ResultOK    = "ok"
+	ResultError = "error"
+)
+
+// Config is the audit section of the config file.
+type Config struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// Path overrides the default location. stackr creates the log
+	// readable by its owner only; to have every user of a shared host log
+	// to the same place, create a group writable file there first, its
+	// mode is left as is.
+	Path string `json:"path,omitempty"`
+}
+
+// Log appends entries to a file. A nil *Log records nothing.
+type Log struct {
+	path   string
+	source string
+	user   string
+	host   string
+
+	mu sync.Mutex
+}
+
+// Open returns a log writing to path. source tells which entry point made
+// the change, such as "tui" or "cli".
+func Open(path, source string) *Log {
+	l := &Log{path: path, source: source, user: os.Getenv("USER")}
+	if u, err := user.Current(); err == nil {
+		l.user = u.Username
+	}
+	l.host, _ = os.Hostname()
+	return l
+}
+
+// Record fills in time, user, host and source and appends e.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	e.Time = time.Now()
+	e.User = l.user
+	e.Host = l.host
+	e.Source = l.source
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	// 0o600 only applies when the file is created here; a file set up
+	// beforehand for a shared log keeps its mode.
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	// One write per entry keeps lines whole with several writers.
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
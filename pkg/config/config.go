@@ -0,0 +1,121 @@
+// Package config loads the user's stackr settings.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/alert"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/audit"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/history"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/notes"
+)
+
+// Config is the content of the config file. Every section is optional.
+type Config struct {
+	Alerts  alert.Config   `json:"alerts"`
+	History history.Config `json:"history"`
+	Audit   audit.Config   `json:"audit"`
+}
+
+// Dir returns the directory stackr keeps its files in,
+// $XDG_CONFIG_HOME/stackr or the platform equivalent.
+func Dir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "stackr"), nil
+}
+
+// DataDir returns the directory stackr keeps recorded data in,
+// $XDG_DATA_HOME/stackr or ~/.local/share/stackr.
+func DataDir() (string, error) {
+	if base := os.Getenv("XDG_DATA_HOME"); base != "" {
+		return filepath.Join(base, "stackr"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "stackr"), nil
+}
+
+// HistoryStore opens the stats history kept under DataDir.
+func HistoryStore(cfg Config) (*history.Store, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return history.Open(filepath.Join(dir, "history"), cfg.History), nil
+}
+
+// NotesStore opens the container notes kept in notes.json under DataDir.
+func NotesStore() (*notes.Store, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return notes.Open(filepath.Join(dir, "notes.json"))
+}
+
+// AuditLog opens the audit log for changes made from source, nil when
+// auditing is disabled. It defaults to audit.jsonl in DataDir.
+func AuditLog(cfg Config, source string) (*audit.Log, error) {
+	if cfg.Audit.Disabled {
+		return nil, nil
+	}
+	p := cfg.Audit.Path
+	if p == "" {
+		dir, err := DataDir()
+		if err != nil {
+			return nil, err
+		}
+		p = filepath.Join(dir, "audit.jsonl")
+	}
+	return audit.Open(p, source), nil
+}
+
+// Path returns the config file location. STACKR_CONFIG overrides the
+// default of config.json in Dir.
+func Path() (string, error) {
+	if p := os.Getenv("STACKR_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// Load reads the config file. A missing file is not an error and yields
+// the zero Config.
+func Load() (Config, error) {
+	var cfg Config
+	p, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", p, err)
+	}
+	if err := cfg.Alerts.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", p, err)
+	}
+	if err := cfg.History.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", p, err)
+	}
+	return cfg, nil
+}
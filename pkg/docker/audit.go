@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/audit"
+)
+
+// SetAuditLog makes the client record every change it makes to l.
+func (c *Client) SetAuditLog(l *audit.Log) {
+	c.audit = l
+}
+
+// audited runs fn and records it as action on the container id. The name
+// is looked up first since the container may be gone afterwards.
+func (c *Client) audited(ctx context.Context, id, action string, params map[string]any, fn func() error) error {
+	if c.audit == nil {
+		return fn()
+	}
+	name := ""
+	if id != "" {
+		if ins, err := c.Inspect(ctx, id); err == nil {
+			name = strings.TrimPrefix(ins.Name, "/")
+			id = ins.ID
+		}
+	}
+	err := fn()
+	return c.record(id, name, action, params, err)
+}
+
+// record appends an entry for an action that returned err. A failing audit
+// log is reported, without hiding the error of the action itself.
+func (c *Client) record(id, name, action string, params map[string]any, err error) error {
+	e := audit.Entry{
+		ContainerID: id,
+		Container:   name,
+		Action:      action,
+		Params:      params,
+		Result:      audit.ResultOK,
+	}
+	if err != nil {
+		e.Result = audit.ResultError
+		e.Error = err.Error()
+	}
+	if aerr := c.audit.Record(e); aerr != nil && err == nil {
+		return fmt.Errorf("%s succeeded but the audit log could not be written: %w", action, aerr)
+	}
+	return err
+}
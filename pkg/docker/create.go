@@ -0,0 +1,506 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/go-units"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+)
+
+// CreateSpec describes a container in the same terms as the docker run
+// flags it maps to, so it can be filled from a form and echoed back as a
+// command line.
+type CreateSpec struct {
+	Image   string
+	Name    string
+	Ports   []string // -p  [ip:]host:container[/proto] or container[/proto]
+	Env     []string // -e  KEY=value
+	Volumes []string // -v  source:target[:ro,z...]
+	Network string   // --network
+	Restart string   // --restart no|always|unless-stopped|on-failure[:N]
+	Memory  string   // --memory 512m, 1g...
+	CPUs    string   // --cpus 1.5
+	Labels  []string // -l  key=value
+	Health  *container.HealthConfig
+	Command []string // trailing command
+
+	MemorySwap string // --memory-swap 1g, -1 for unlimited
+	CPUShares  string // --cpu-shares 1024
+	PidsLimit  string // --pids-limit 200, -1 for unlimited
+
+	// Entrypoint overrides the image's; docker run only takes the first
+	// element as --entrypoint, the rest goes before Command.
+	Entrypoint []string
+
+	// ExtraNetworks are connected after creation; docker run only accepts
+	// one --network.
+	ExtraNetworks []string
+	// Aliases are extra DNS names on every user defined network.
+	Aliases []string // --network-alias
+}
+
+// volumeOptions are the options docker run -v takes after the target,
+// comma separated: access mode, SELinux label, propagation and copy mode.
+var volumeOptions = map[string]bool{
+	"ro": true, "rw": true,
+	"z": true, "Z": true,
+	"shared": true, "rshared": true, "slave": true, "rslave": true, "private": true, "rprivate": true,
+	"nocopy": true, "consistent": true, "cached": true, "delegated": true,
+}
+
+// Validate reports the first problem that would make Create fail before
+// anything is sent to the daemon.
+func (s CreateSpec) Validate() error {
+	_, err := s.options()
+	return err
+}
+
+func (s CreateSpec) options() (client.ContainerCreateOptions, error) {
+	if strings.TrimSpace(s.Image) == "" {
+		return client.ContainerCreateOptions{}, fmt.Errorf("image is required")
+	}
+
+	cfg := &container.Config{
+		Image: strings.TrimSpace(s.Image),
+		Env:   s.Env,
+	}
+	host := &container.HostConfig{
+		Binds: s.Volumes,
+	}
+
+	for _, env := range s.Env {
+		if k, _, _ := strings.Cut(env, "="); k == "" {
+			return client.ContainerCreateOptions{}, fmt.Errorf("invalid env %q: expected KEY=value", env)
+		}
+	}
+
+	if len(s.Labels) > 0 {
+		cfg.Labels = map[string]string{}
+		for _, l := range s.Labels {
+			k, v, _ := strings.Cut(l, "=")
+			if k == "" {
+				return client.ContainerCreateOptions{}, fmt.Errorf("invalid label %q: expected key=value", l)
+			}
+			cfg.Labels[k] = v
+		}
+	}
+
+	if s.Health != nil {
+		cfg.Healthcheck = s.Health
+	}
+
+	for _, v := range s.Volumes {
+		parts := strings.Split(v, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return client.ContainerCreateOptions{}, fmt.Errorf("invalid volume %q: expected source:target[:options]", v)
+		}
+		if len(parts) == 3 {
+			for _, opt := range strings.Split(parts[2], ",") {
+				if !volumeOptions[opt] {
+					return client.ContainerCreateOptions{}, fmt.Errorf("invalid volume option %q in %q", opt, v)
+				}
+			}
+		}
+	}
+
+	if len(s.Ports) > 0 {
+		cfg.ExposedPorts = network.PortSet{}
+		host.PortBindings = network.PortMap{}
+		for _, p := range s.Ports {
+			port, binding, err := parsePortSpec(p)
+			if err != nil {
+				return client.ContainerCreateOptions{}, err
+			}
+			cfg.ExposedPorts[port] = struct{}{}
+			if binding != nil {
+				host.PortBindings[port] = append(host.PortBindings[port], *binding)
+			}
+		}
+	}
+
+	var netCfg *network.NetworkingConfig
+	if s.Network != "" {
+		host.NetworkMode = container.NetworkMode(s.Network)
+		if len(s.Aliases) > 0 && host.NetworkMode.IsUserDefined() {
+			netCfg = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{
+				s.Network: {Aliases: s.Aliases},
+			}}
+		}
+	}
+
+	if s.Restart != "" {
+		policy, err := ParseRestartPolicy(s.Restart)
+		if err != nil {
+			return client.ContainerCreateOptions{}, err
+		}
+		host.RestartPolicy = policy
+	}
+
+	if s.Memory != "" {
+		mem, err := units.RAMInBytes(s.Memory)
+		if err != nil {
+			return client.ContainerCreateOptions{}, fmt.Errorf("invalid memory %q: %w", s.Memory, err)
+		}
+		host.Memory = mem
+	}
+
+	if s.CPUs != "" {
+		cpus, err := strconv.ParseFloat(s.CPUs, 64)
+		if err != nil || cpus <= 0 {
+			return client.ContainerCreateOptions{}, fmt.Errorf("invalid cpus %q: expected a positive number", s.CPUs)
+		}
+		host.NanoCPUs = int64(cpus * 1e9)
+	}
+
+	if s.MemorySwap != "" {
+		host.MemorySwap = -1
+		if !isUnlimited(s.MemorySwap) {
+			swap, err := units.RAMInBytes(s.MemorySwap)
+			if err != nil {
+				return client.ContainerCreateOptions{}, fmt.Errorf("invalid memory swap %q: %w", s.MemorySwap, err)
+			}
+			if swap < host.Memory {
+				return client.ContainerCreateOptions{}, fmt.Errorf("memory swap must be at least the memory limit")
+			}
+			host.MemorySwap = swap
+		}
+	}
+
+	if s.CPUShares != "" {
+		shares, err := strconv.ParseInt(s.CPUShares, 10, 64)
+		if err != nil || shares < 2 {
+			return client.ContainerCreateOptions{}, fmt.Errorf("invalid cpu shares %q: expected an integer >= 2", s.CPUShares)
+		}
+		host.CPUShares = shares
+	}
+
+	if s.PidsLimit != "" {
+		limit := int64(-1)
+		if !isUnlimited(s.PidsLimit) {
+			var err error
+			limit, err = strconv.ParseInt(s.PidsLimit, 10, 64)
+			if err != nil || limit <= 0 {
+				return client.ContainerCreateOptions{}, fmt.Errorf("invalid pids limit %q: expected a positive number or -1", s.PidsLimit)
+			}
+		}
+		host.PidsLimit = &limit
+	}
+
+	if len(s.Command) > 0 {
+		cfg.Cmd = s.Command
+	}
+	if len(s.Entrypoint) > 0 {
+		cfg.Entrypoint = s.Entrypoint
+	}
+
+	return client.ContainerCreateOptions{
+		Config:           cfg,
+		HostConfig:       host,
+		NetworkingConfig: netCfg,
+		Name:             strings.TrimSpace(s.Name),
+	}, nil
+}
+
+// RunCommand renders the spec as the equivalent docker run command line.
+func (s CreateSpec) RunCommand() string {
+	return s.runCommand(" ")
+}
+
+// RunScript is RunCommand broken into one flag per line, which is easier to
+// read and still pastes into a shell.
+func (s CreateSpec) RunScript() string {
+	return s.runCommand(" \\\n  ")
+}
+
+func (s CreateSpec) runCommand(sep string) string {
+	groups := [][]string{{"docker", "run", "-d"}}
+	flag := func(name, value string) {
+		groups = append(groups, []string{name, shellQuote(value)})
+	}
+
+	if s.Name != "" {
+		flag("--name", s.Name)
+	}
+	for _, p := range s.Ports {
+		flag("-p", p)
+	}
+	for _, e := range s.Env {
+		flag("-e", e)
+	}
+	for _, v := range s.Volumes {
+		flag("-v", v)
+	}
+	if s.Network != "" {
+		flag("--network", s.Network)
+	}
+	for _, a := range s.Aliases {
+		flag("--network-alias", a)
+	}
+	if s.Restart != "" {
+		flag("--restart", s.Restart)
+	}
+	if s.Memory != "" {
+		flag("--memory", s.Memory)
+	}
+	if s.MemorySwap != "" {
+		flag("--memory-swap", s.MemorySwap)
+	}
+	if s.CPUs != "" {
+		flag("--cpus", s.CPUs)
+	}
+	if s.CPUShares != "" {
+		flag("--cpu-shares", s.CPUShares)
+	}
+	if s.PidsLimit != "" {
+		flag("--pids-limit", s.PidsLimit)
+	}
+	for _, l := range s.Labels {
+		flag("-l", l)
+	}
+	health := healthFlags(s.Health)
+	for i := 0; i < len(health); i += 2 {
+		groups = append(groups, health[i:min(i+2, len(health))])
+	}
+
+	args := s.Command
+	if len(s.Entrypoint) > 0 {
+		flag("--entrypoint", s.Entrypoint[0])
+		args = append(append([]string{}, s.Entrypoint[1:]...), s.Command...)
+	}
+
+	last := []string{shellQuote(s.Image)}
+	for _, c := range args {
+		last = append(last, shellQuote(c))
+	}
+	groups = append(groups, last)
+
+	lines := make([]string, len(groups))
+	for i, g := range groups {
+		lines[i] = strings.Join(g, " ")
+	}
+	cmd := strings.Join(lines, sep)
+
+	if len(s.ExtraNetworks) > 0 {
+		name := s.Name
+		if name == "" {
+			name = "<container>"
+		}
+		for _, n := range s.ExtraNetworks {
+			connect := "docker network connect "
+			for _, a := range s.Aliases {
+				connect += "--alias " + shellQuote(a) + " "
+			}
+			cmd += " &&" + sep + connect + shellQuote(n) + " " + shellQuote(name)
+		}
+	}
+	return cmd
+}
+
+func healthFlags(h *container.HealthConfig) []string {
+	if h == nil || len(h.Test) == 0 {
+		return nil
+	}
+	switch h.Test[0] {
+	case "NONE":
+		return []string{"--no-healthcheck"}
+	case "CMD-SHELL":
+		if len(h.Test) < 2 {
+			return nil
+		}
+	}
+
+	args := []string{"--health-cmd", shellQuote(healthCommand(h))}
+	if h.Interval > 0 {
+		args = append(args, "--health-interval", h.Interval.String())
+	}
+	if h.Timeout > 0 {
+		args = append(args, "--health-timeout", h.Timeout.String())
+	}
+	if h.StartPeriod > 0 {
+		args = append(args, "--health-start-period", h.StartPeriod.String())
+	}
+	if h.Retries > 0 {
+		args = append(args, "--health-retries", strconv.Itoa(h.Retries))
+	}
+	return args
+}
+
+// healthCommand flattens a healthcheck test into the shell string that
+// --health-cmd expects.
+func healthCommand(h *container.HealthConfig) string {
+	switch h.Test[0] {
+	case "CMD-SHELL":
+		return h.Test[1]
+	case "CMD":
+		quoted := make([]string, 0, len(h.Test)-1)
+		for _, a := range h.Test[1:] {
+			quoted = append(quoted, shellQuote(a))
+		}
+		return strings.Join(quoted, " ")
+	}
+	return strings.Join(h.Test, " ")
+}
+
+// Create validates the spec, pulls the image if it isn't present locally,
+// creates the container and returns its ID. It does not start it.
+func (c *Client) Create(ctx context.Context, spec CreateSpec) (string, error) {
+	id, err := c.create(ctx, spec)
+	if c.audit != nil {
+		err = c.record(id, spec.Name, "create", spec.auditParams(), err)
+	}
+	return id, err
+}
+
+// auditParams is what the audit log records of a spec. Env values are left
+// out, they often hold secrets.
+func (s CreateSpec) auditParams() map[string]any {
+	keys := make([]string, 0, len(s.Env))
+	for _, e := range s.Env {
+		k, _, _ := strings.Cut(e, "=")
+		keys = append(keys, k)
+	}
+	return map[string]any{"image": s.Image, "name": s.Name, "env": keys}
+}
+
+func (c *Client) create(ctx context.Context, spec CreateSpec) (string, error) {
+	opts, err := spec.options()
+	if err != nil {
+		return "", err
+	}
+	networks := map[string]*network.EndpointSettings{}
+	for _, n := range spec.ExtraNetworks {
+		ep := &network.EndpointSettings{}
+		if len(spec.Aliases) > 0 {
+			ep.Aliases = spec.Aliases
+		}
+		networks[n] = ep
+	}
+	return c.createWith(ctx, opts, networks)
+}
+
+// createWith creates the container from opts, pulling the image if it isn't
+// present locally, and connects it to networks: older daemons take a single
+// endpoint at creation. A container that can't be connected is removed
+// again, it would otherwise run without the networks it needs.
+func (c *Client) createWith(ctx context.Context, opts client.ContainerCreateOptions, networks map[string]*network.EndpointSettings) (string, error) {
+	// Only a missing image is worth a pull; the create below reports a
+	// missing network or volume the same way.
+	if _, err := c.cli.ImageInspect(ctx, opts.Config.Image); cerrdefs.IsNotFound(err) {
+		if err := c.pull(ctx, opts.Config.Image); err != nil {
+			return "", err
+		}
+	}
+	result, err := c.cli.ContainerCreate(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	for _, n := range sortedKeys(networks) {
+		opts := client.NetworkConnectOptions{Container: result.ID, EndpointConfig: networks[n]}
+		if _, err := c.cli.NetworkConnect(ctx, n, opts); err != nil {
+			err = fmt.Errorf("connect %s: %w", n, err)
+			if _, rerr := c.cli.ContainerRemove(ctx, result.ID, client.ContainerRemoveOptions{Force: true}); rerr != nil {
+				return "", fmt.Errorf("%w; the created container %.12s could not be removed: %v", err, result.ID, rerr)
+			}
+			return "", err
+		}
+	}
+	return result.ID, nil
+}
+
+func (c *Client) Pull(ctx context.Context, image string) error {
+	err := c.pull(ctx, image)
+	if c.audit != nil {
+		err = c.record("", "", "pull", map[string]any{"image": image}, err)
+	}
+	return err
+}
+
+func (c *Client) pull(ctx context.Context, image string) error {
+	resp, err := c.cli.ImagePull(ctx, image, client.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	return resp.Wait(ctx)
+}
+
+// ParseRestartPolicy accepts the docker run --restart syntax.
+func ParseRestartPolicy(s string) (container.RestartPolicy, error) {
+	name, count, hasCount := strings.Cut(s, ":")
+	policy := container.RestartPolicy{Name: container.RestartPolicyMode(name)}
+	if hasCount {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return container.RestartPolicy{}, fmt.Errorf("invalid restart retry count %q", count)
+		}
+		policy.MaximumRetryCount = n
+	}
+	if err := container.ValidateRestartPolicy(policy); err != nil {
+		return container.RestartPolicy{}, err
+	}
+	return policy, nil
+}
+
+func parsePortSpec(s string) (network.Port, *network.PortBinding, error) {
+	spec, proto, _ := strings.Cut(s, "/")
+	parts := strings.Split(spec, ":")
+	// An IPv6 host IP is bracketed, its colons are not separators.
+	if rest, ok := strings.CutPrefix(spec, "["); ok {
+		ip, ports, _ := strings.Cut(rest, "]")
+		parts = append([]string{ip}, strings.Split(strings.TrimPrefix(ports, ":"), ":")...)
+		if !strings.HasPrefix(ports, ":") || len(parts) != 3 {
+			return network.Port{}, nil, fmt.Errorf("invalid port %q: expected [ip]:host:container[/proto]", s)
+		}
+	}
+
+	var hostIP, hostPort, ctrPort string
+	switch len(parts) {
+	case 1:
+		ctrPort = parts[0]
+	case 2:
+		hostPort, ctrPort = parts[0], parts[1]
+	case 3:
+		hostIP, hostPort, ctrPort = parts[0], parts[1], parts[2]
+	default:
+		return network.Port{}, nil, fmt.Errorf("invalid port %q: expected [ip:]host:container[/proto]", s)
+	}
+
+	if proto != "" {
+		ctrPort += "/" + proto
+	}
+	port, err := network.ParsePort(ctrPort)
+	if err != nil {
+		return network.Port{}, nil, err
+	}
+	if len(parts) == 1 {
+		// Published on a port the daemon picks, as docker run -p does.
+		return port, &network.PortBinding{}, nil
+	}
+
+	if _, err := strconv.ParseUint(hostPort, 10, 16); hostPort != "" && err != nil {
+		return network.Port{}, nil, fmt.Errorf("invalid host port %q", hostPort)
+	}
+	binding := &network.PortBinding{HostPort: hostPort}
+	if hostIP != "" {
+		addr, err := netip.ParseAddr(hostIP)
+		if err != nil {
+			return network.Port{}, nil, fmt.Errorf("invalid host ip %q", hostIP)
+		}
+		binding.HostIP = addr
+	}
+	return port, binding, nil
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}();&|<>#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
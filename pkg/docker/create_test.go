@@ -0,0 +1,61 @@
+package docker
+
+import "testing"
+
+func TestParsePortSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		port     string
+		hostIP   string
+		hostPort string
+	}{
+		// A lone container port is published on a port the daemon picks.
+		{"80", "80/tcp", "", ""},
+		{"53/udp", "53/udp", "", ""},
+		{"8080:80", "80/tcp", "", "8080"},
+		{"127.0.0.1:8080:80/tcp", "80/tcp", "127.0.0.1", "8080"},
+		{"127.0.0.1::80", "80/tcp", "127.0.0.1", ""},
+		{"[::1]:8080:80", "80/tcp", "::1", "8080"},
+		{"[fd00::2]::53/udp", "53/udp", "fd00::2", ""},
+	}
+	for _, tt := range tests {
+		port, binding, err := parsePortSpec(tt.spec)
+		if err != nil {
+			t.Errorf("%s: %v", tt.spec, err)
+			continue
+		}
+		if port.String() != tt.port {
+			t.Errorf("%s: port = %s, want %s", tt.spec, port, tt.port)
+		}
+		if binding == nil {
+			t.Errorf("%s: not published", tt.spec)
+			continue
+		}
+		hostIP := ""
+		if binding.HostIP.IsValid() {
+			hostIP = binding.HostIP.String()
+		}
+		if hostIP != tt.hostIP || binding.HostPort != tt.hostPort {
+			t.Errorf("%s: binding = %q:%q, want %q:%q", tt.spec, hostIP, binding.HostPort, tt.hostIP, tt.hostPort)
+		}
+	}
+
+	for _, spec := range []string{"", "a:b:c:d", "x:80", "70000:80", "[::1]:80", "[::1", "::1:8080:80"} {
+		if _, _, err := parsePortSpec(spec); err == nil {
+			t.Errorf("%s: no error", spec)
+		}
+	}
+}
+
+func TestCreateSpecVolumeOptions(t *testing.T) {
+	for _, v := range []string{"data:/data", "/srv:/srv:ro", "/srv:/srv:z", "/srv:/srv:Z", "/srv:/srv:ro,z", "/srv:/srv:rw,rslave"} {
+		if err := (CreateSpec{Image: "app", Volumes: []string{v}}).Validate(); err != nil {
+			t.Errorf("%s: %v", v, err)
+		}
+	}
+	for _, v := range []string{"/srv", ":/srv", "/srv:/srv:rx", "/srv:/srv:ro,", "/srv:/srv:ro:z"} {
+		if err := (CreateSpec{Image: "app", Volumes: []string{v}}).Validate(); err == nil {
+			t.Errorf("%s: no error", v)
+		}
+	}
+}
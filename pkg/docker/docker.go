@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/aogirikarma/mini-stackr-cli/pkg/audit"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
 	"github.com/moby/moby/client"
 )
 
 type Client struct {
-	cli *client.Client
+	cli   *client.Client
+	audit *audit.Log
 }
 
 func NewClient() (*Client, error) {
@@ -40,8 +43,21 @@ func (c *Client) Inspect(ctx context.Context, id string) (container.InspectRespo
 	return result.Container, nil
 }
 
+// Stats takes a single reading, without the previous sample CPU usage is
+// computed against, see stats.Sampler.
 func (c *Client) Stats(ctx context.Context, id string) (*container.StatsResponse, error) {
-	result, err := c.cli.ContainerStats(ctx, id, client.ContainerStatsOptions{Stream: false})
+	return c.stats(ctx, id, client.ContainerStatsOptions{})
+}
+
+// StatsWithPrevious is Stats with the daemon taking two readings a second
+// apart, so the result carries the previous sample. It takes about a
+// second.
+func (c *Client) StatsWithPrevious(ctx context.Context, id string) (*container.StatsResponse, error) {
+	return c.stats(ctx, id, client.ContainerStatsOptions{IncludePreviousSample: true})
+}
+
+func (c *Client) stats(ctx context.Context, id string, opts client.ContainerStatsOptions) (*container.StatsResponse, error) {
+	result, err := c.cli.ContainerStats(ctx, id, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -55,21 +71,61 @@ func (c *Client) Stats(ctx context.Context, id string) (*container.StatsResponse
 }
 
 func (c *Client) Stop(ctx context.Context, id string) error {
-	_, err := c.cli.ContainerStop(ctx, id, client.ContainerStopOptions{})
-	return err
+	return c.audited(ctx, id, "stop", nil, func() error { return c.stop(ctx, id) })
 }
 
 func (c *Client) Start(ctx context.Context, id string) error {
-	_, err := c.cli.ContainerStart(ctx, id, client.ContainerStartOptions{})
-	return err
+	return c.audited(ctx, id, "start", nil, func() error { return c.start(ctx, id) })
 }
 
 func (c *Client) Restart(ctx context.Context, id string) error {
-	_, err := c.cli.ContainerRestart(ctx, id, client.ContainerRestartOptions{})
-	return err
+	return c.audited(ctx, id, "restart", nil, func() error {
+		_, err := c.cli.ContainerRestart(ctx, id, client.ContainerRestartOptions{})
+		return err
+	})
 }
 
 func (c *Client) Remove(ctx context.Context, id string) error {
+	return c.audited(ctx, id, "remove", nil, func() error { return c.remove(ctx, id) })
+}
+
+// stop, start and remove are the unaudited steps Recreate is made of.
+
+func (c *Client) stop(ctx context.Context, id string) error {
+	_, err := c.cli.ContainerStop(ctx, id, client.ContainerStopOptions{})
+	return err
+}
+
+func (c *Client) start(ctx context.Context, id string) error {
+	_, err := c.cli.ContainerStart(ctx, id, client.ContainerStartOptions{})
+	return err
+}
+
+func (c *Client) remove(ctx context.Context, id string) error {
 	_, err := c.cli.ContainerRemove(ctx, id, client.ContainerRemoveOptions{})
 	return err
-}
\ No newline at end of file
+}
+
+func (c *Client) Top(ctx context.Context, id string) (container.TopResponse, error) {
+	result, err := c.cli.ContainerTop(ctx, id, client.ContainerTopOptions{Arguments: []string{"aux"}})
+	if err != nil {
+		return container.TopResponse{}, err
+	}
+	return container.TopResponse{Titles: result.Titles, Processes: result.Processes}, nil
+}
+
+func (c *Client) Kill(ctx context.Context, id, signal string) error {
+	return c.audited(ctx, id, "kill", map[string]any{"signal": signal}, func() error {
+		_, err := c.cli.ContainerKill(ctx, id, client.ContainerKillOptions{Signal: signal})
+		return err
+	})
+}
+
+// Events streams container events until ctx is cancelled or the connection
+// drops, in which case the error channel receives the reason.
+func (c *Client) Events(ctx context.Context) (<-chan events.Message, <-chan error) {
+	result := c.cli.Events(ctx, client.EventsListOptions{
+		Filters: make(client.Filters).Add("type", string(events.ContainerEventType)),
+	})
+	return result.Messages, result.Err
+}
@@ -0,0 +1,347 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/network"
+)
+
+// SpecFromInspect rebuilds the CreateSpec a container was started with, so
+// it can be re-rendered as a docker run command or a compose service, or
+// recreated. img is the config of the image it runs, see ImageConfig: the
+// env, labels, command and healthcheck the container inherited from it are
+// left out, so they don't outlive a change of image. With a nil img they
+// are all kept.
+func SpecFromInspect(ins container.InspectResponse, img *dockerspec.DockerOCIImageConfig) CreateSpec {
+	spec := CreateSpec{
+		Name: strings.TrimPrefix(ins.Name, "/"),
+	}
+	if img == nil {
+		img = &dockerspec.DockerOCIImageConfig{}
+	}
+
+	if cfg := ins.Config; cfg != nil {
+		spec.Image = cfg.Image
+		for _, e := range cfg.Env {
+			if !slices.Contains(img.Env, e) {
+				spec.Env = append(spec.Env, e)
+			}
+		}
+		// Setting an entrypoint drops the image's command, so the command
+		// is only inherited along with the entrypoint.
+		if !slices.Equal(cfg.Entrypoint, img.Entrypoint) {
+			spec.Entrypoint = cfg.Entrypoint
+			spec.Command = cfg.Cmd
+		} else if !slices.Equal(cfg.Cmd, img.Cmd) {
+			spec.Command = cfg.Cmd
+		}
+		if !sameHealth(cfg.Healthcheck, img.Healthcheck) {
+			spec.Health = cfg.Healthcheck
+		}
+
+		for _, k := range sortedKeys(cfg.Labels) {
+			if v, ok := img.Labels[k]; !ok || v != cfg.Labels[k] {
+				spec.Labels = append(spec.Labels, k+"="+cfg.Labels[k])
+			}
+		}
+	}
+
+	for _, m := range ins.Mounts {
+		src := m.Source
+		if m.Type == mount.TypeVolume {
+			src = m.Name
+		}
+		if src == "" {
+			continue
+		}
+		v := src + ":" + m.Destination
+		if !m.RW {
+			v += ":ro"
+		}
+		spec.Volumes = append(spec.Volumes, v)
+	}
+
+	if hc := ins.HostConfig; hc != nil {
+		for port, bindings := range hc.PortBindings {
+			for _, b := range bindings {
+				p := fmt.Sprintf("%s:%d/%s", b.HostPort, port.Num(), port.Proto())
+				switch ip := b.HostIP; {
+				case !ip.IsValid() || ip.IsUnspecified():
+				case ip.Is6() && !ip.Is4In6():
+					p = "[" + ip.String() + "]:" + p
+				default:
+					p = ip.Unmap().String() + ":" + p
+				}
+				spec.Ports = append(spec.Ports, p)
+			}
+		}
+		sort.Strings(spec.Ports)
+
+		if mode := string(hc.NetworkMode); mode != "" && mode != "default" && mode != "bridge" {
+			spec.Network = mode
+		}
+
+		if !hc.RestartPolicy.IsNone() {
+			spec.Restart = string(hc.RestartPolicy.Name)
+			if hc.RestartPolicy.IsOnFailure() && hc.RestartPolicy.MaximumRetryCount > 0 {
+				spec.Restart += ":" + strconv.Itoa(hc.RestartPolicy.MaximumRetryCount)
+			}
+		}
+
+		if hc.Memory > 0 {
+			spec.Memory = FormatMemory(hc.Memory)
+		}
+		// The daemon doubles the memory limit for swap unless told
+		// otherwise.
+		switch {
+		case hc.MemorySwap < 0:
+			spec.MemorySwap = "-1"
+		case hc.MemorySwap > 0 && hc.MemorySwap != 2*hc.Memory:
+			spec.MemorySwap = FormatMemory(hc.MemorySwap)
+		}
+		if hc.NanoCPUs > 0 {
+			spec.CPUs = strconv.FormatFloat(float64(hc.NanoCPUs)/1e9, 'f', -1, 64)
+		}
+		if hc.CPUShares > 0 {
+			spec.CPUShares = strconv.FormatInt(hc.CPUShares, 10)
+		}
+		if hc.PidsLimit != nil && *hc.PidsLimit != 0 {
+			spec.PidsLimit = strconv.FormatInt(max(*hc.PidsLimit, -1), 10)
+		}
+	}
+
+	if ins.NetworkSettings != nil {
+		for _, n := range sortedKeys(ins.NetworkSettings.Networks) {
+			if n != spec.Network && n != "bridge" {
+				spec.ExtraNetworks = append(spec.ExtraNetworks, n)
+			}
+		}
+		if ep := ins.NetworkSettings.Networks[spec.Network]; ep != nil {
+			spec.Aliases = ep.Aliases
+		}
+	}
+
+	return spec
+}
+
+// Drift compares the image, environment and published ports of spec with
+// the container ins, and describes the first that differs, "" when none
+// does. Only the variables spec sets are compared: the container also holds
+// those of its image.
+func Drift(spec CreateSpec, ins container.InspectResponse) (string, error) {
+	opts, err := spec.options()
+	if err != nil {
+		return "", err
+	}
+	if ins.Config == nil || ins.HostConfig == nil {
+		return "", fmt.Errorf("inspect of %.12s has no config", ins.ID)
+	}
+	if ins.Config.Image != opts.Config.Image {
+		return "image changed", nil
+	}
+	for _, e := range opts.Config.Env {
+		if !slices.Contains(ins.Config.Env, e) {
+			return "environment changed", nil
+		}
+	}
+	if !slices.Equal(bindings(opts.HostConfig.PortBindings), bindings(ins.HostConfig.PortBindings)) {
+		return "ports changed", nil
+	}
+	return "", nil
+}
+
+// bindings flattens port bindings into a sorted list, an unspecified host
+// IP being the same as none.
+func bindings(ports network.PortMap) []string {
+	var out []string
+	for port, bs := range ports {
+		for _, b := range bs {
+			ip := ""
+			if b.HostIP.IsValid() && !b.HostIP.IsUnspecified() {
+				ip = b.HostIP.Unmap().String()
+			}
+			out = append(out, ip+"|"+b.HostPort+"|"+port.String())
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sameHealth(a, b *container.HealthConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return slices.Equal(a.Test, b.Test) && a.Interval == b.Interval && a.Timeout == b.Timeout &&
+		a.StartPeriod == b.StartPeriod && a.StartInterval == b.StartInterval && a.Retries == b.Retries
+}
+
+// FormatMemory renders a byte count in the largest unit docker's --memory
+// flag accepts without losing precision.
+func FormatMemory(b int64) string {
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}} {
+		if b%u.size == 0 {
+			return strconv.FormatInt(b/u.size, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(b, 10)
+}
+
+// ComposeFile renders the spec as a standalone compose.yaml with a single
+// service. Networks other than the defaults are declared external since they
+// already exist on the host.
+func (s CreateSpec) ComposeFile() string {
+	service := s.Name
+	if service == "" {
+		service = "app"
+	}
+
+	var b strings.Builder
+	b.WriteString("services:\n")
+	fmt.Fprintf(&b, "  %s:\n", service)
+	fmt.Fprintf(&b, "    image: %s\n", yamlString(s.Image))
+	if s.Name != "" {
+		fmt.Fprintf(&b, "    container_name: %s\n", yamlString(s.Name))
+	}
+	if len(s.Entrypoint) > 0 {
+		fmt.Fprintf(&b, "    entrypoint: %s\n", yamlList(s.Entrypoint))
+	}
+	if len(s.Command) > 0 {
+		fmt.Fprintf(&b, "    command: %s\n", yamlList(s.Command))
+	}
+	if s.Restart != "" {
+		fmt.Fprintf(&b, "    restart: %s\n", yamlString(s.Restart))
+	}
+	writeYAMLSeq(&b, "ports", s.Ports)
+	if len(s.Env) > 0 {
+		b.WriteString("    environment:\n")
+		for _, e := range s.Env {
+			k, v, _ := strings.Cut(e, "=")
+			fmt.Fprintf(&b, "      %s: %s\n", yamlString(k), yamlString(v))
+		}
+	}
+	writeYAMLSeq(&b, "volumes", s.Volumes)
+
+	networks := s.ExtraNetworks
+	switch {
+	case s.Network == "host" || s.Network == "none" || strings.HasPrefix(s.Network, "container:"):
+		fmt.Fprintf(&b, "    network_mode: %s\n", yamlString(s.Network))
+	case s.Network != "":
+		networks = append([]string{s.Network}, networks...)
+	}
+	if len(networks) > 0 {
+		b.WriteString("    networks:\n")
+		for _, n := range networks {
+			fmt.Fprintf(&b, "      - %s\n", n)
+		}
+	}
+
+	if s.Memory != "" {
+		fmt.Fprintf(&b, "    mem_limit: %s\n", yamlString(s.Memory))
+	}
+	if s.MemorySwap != "" {
+		fmt.Fprintf(&b, "    memswap_limit: %s\n", yamlString(s.MemorySwap))
+	}
+	if s.CPUs != "" {
+		fmt.Fprintf(&b, "    cpus: %s\n", s.CPUs)
+	}
+	if s.CPUShares != "" {
+		fmt.Fprintf(&b, "    cpu_shares: %s\n", s.CPUShares)
+	}
+	if s.PidsLimit != "" {
+		fmt.Fprintf(&b, "    pids_limit: %s\n", s.PidsLimit)
+	}
+	if len(s.Labels) > 0 {
+		b.WriteString("    labels:\n")
+		for _, l := range s.Labels {
+			k, v, _ := strings.Cut(l, "=")
+			fmt.Fprintf(&b, "      %s: %s\n", yamlString(k), yamlString(v))
+		}
+	}
+	if h := s.Health; h != nil && len(h.Test) > 0 {
+		b.WriteString("    healthcheck:\n")
+		if h.Test[0] == "NONE" {
+			b.WriteString("      disable: true\n")
+		} else {
+			fmt.Fprintf(&b, "      test: %s\n", yamlList(h.Test))
+			writeYAMLDuration(&b, "interval", h.Interval)
+			writeYAMLDuration(&b, "timeout", h.Timeout)
+			writeYAMLDuration(&b, "start_period", h.StartPeriod)
+			if h.Retries > 0 {
+				fmt.Fprintf(&b, "      retries: %d\n", h.Retries)
+			}
+		}
+	}
+
+	if len(networks) > 0 {
+		b.WriteString("\nnetworks:\n")
+		for _, n := range networks {
+			fmt.Fprintf(&b, "  %s:\n    external: true\n", n)
+		}
+	}
+
+	return b.String()
+}
+
+func writeYAMLSeq(b *strings.Builder, key string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "    %s:\n", key)
+	for _, it := range items {
+		fmt.Fprintf(b, "      - %s\n", yamlString(it))
+	}
+}
+
+func writeYAMLDuration(b *strings.Builder, key string, d time.Duration) {
+	if d > 0 {
+		fmt.Fprintf(b, "      %s: %s\n", key, d)
+	}
+}
+
+// yamlString quotes s as a JSON string, which is always a valid YAML
+// scalar and spares us from YAML's own quoting rules. Dollars are doubled
+// so compose doesn't try to interpolate them.
+func yamlString(s string) string {
+	out, _ := json.Marshal(strings.ReplaceAll(s, "$", "$$"))
+	return string(out)
+}
+
+func yamlList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, it := range items {
+		quoted[i] = yamlString(it)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ImageConfig returns the config of the image the container runs, what it
+// inherits unless told otherwise. See SpecFromInspect.
+func (c *Client) ImageConfig(ctx context.Context, ins container.InspectResponse) (*dockerspec.DockerOCIImageConfig, error) {
+	img, err := c.cli.ImageInspect(ctx, ins.Image)
+	if err != nil {
+		return nil, err
+	}
+	return img.Config, nil
+}
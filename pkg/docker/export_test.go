@@ -0,0 +1,200 @@
+package docker
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func postgresImage() *dockerspec.DockerOCIImageConfig {
+	return &dockerspec.DockerOCIImageConfig{
+		ImageConfig: ocispec.ImageConfig{
+			Env:        []string{"PATH=/usr/local/bin:/usr/bin", "PG_MAJOR=15"},
+			Entrypoint: []string{"docker-entrypoint.sh"},
+			Cmd:        []string{"postgres"},
+			Labels:     map[string]string{"maintainer": "postgres"},
+		},
+		DockerOCIImageConfigExt: dockerspec.DockerOCIImageConfigExt{
+			Healthcheck: &container.HealthConfig{Test: []string{"CMD", "pg_isready"}},
+		},
+	}
+}
+
+func TestSpecFromInspectImageDefaults(t *testing.T) {
+	ins := container.InspectResponse{
+		Name: "/db",
+		Config: &container.Config{
+			Image:       "postgres:15",
+			Env:         []string{"PATH=/usr/local/bin:/usr/bin", "PG_MAJOR=15", "POSTGRES_PASSWORD=secret"},
+			Entrypoint:  []string{"docker-entrypoint.sh"},
+			Cmd:         []string{"postgres"},
+			Labels:      map[string]string{"maintainer": "postgres", "team": "data"},
+			Healthcheck: &container.HealthConfig{Test: []string{"CMD", "pg_isready"}},
+		},
+	}
+
+	spec := SpecFromInspect(ins, postgresImage())
+	if want := []string{"POSTGRES_PASSWORD=secret"}; !slices.Equal(spec.Env, want) {
+		t.Errorf("Env = %q, want %q", spec.Env, want)
+	}
+	if spec.Entrypoint != nil || spec.Command != nil {
+		t.Errorf("Entrypoint, Command = %q, %q, want the image's", spec.Entrypoint, spec.Command)
+	}
+	if want := []string{"team=data"}; !slices.Equal(spec.Labels, want) {
+		t.Errorf("Labels = %q, want %q", spec.Labels, want)
+	}
+	if spec.Health != nil {
+		t.Errorf("Health = %+v, want the image's", spec.Health)
+	}
+
+	// Without the image everything is kept.
+	spec = SpecFromInspect(ins, nil)
+	if len(spec.Env) != 3 || len(spec.Labels) != 2 || spec.Health == nil || spec.Command == nil {
+		t.Errorf("spec without image = %+v, want the container's config as is", spec)
+	}
+}
+
+func TestSpecFromInspectOverrides(t *testing.T) {
+	ins := container.InspectResponse{
+		Name: "/db",
+		Config: &container.Config{
+			Image:      "postgres:15",
+			Env:        []string{"PATH=/usr/local/bin:/usr/bin", "PG_MAJOR=16"},
+			Entrypoint: []string{"docker-entrypoint.sh"},
+			Cmd:        []string{"postgres", "-c", "fsync=off"},
+			Labels:     map[string]string{"maintainer": "me"},
+			Healthcheck: &container.HealthConfig{
+				Test:     []string{"CMD", "pg_isready"},
+				Interval: 5e9,
+			},
+		},
+	}
+
+	spec := SpecFromInspect(ins, postgresImage())
+	if want := []string{"PG_MAJOR=16"}; !slices.Equal(spec.Env, want) {
+		t.Errorf("Env = %q, want %q", spec.Env, want)
+	}
+	if spec.Entrypoint != nil || !slices.Equal(spec.Command, ins.Config.Cmd) {
+		t.Errorf("Entrypoint, Command = %q, %q, want the image's entrypoint and %q", spec.Entrypoint, spec.Command, ins.Config.Cmd)
+	}
+	if want := []string{"maintainer=me"}; !slices.Equal(spec.Labels, want) {
+		t.Errorf("Labels = %q, want %q", spec.Labels, want)
+	}
+	if spec.Health == nil {
+		t.Error("Health = nil, want the changed interval kept")
+	}
+
+	// A new entrypoint drops the image's command, whatever the container
+	// runs must be kept with it.
+	ins.Config.Entrypoint = []string{"sh", "-c"}
+	ins.Config.Cmd = []string{"postgres"}
+	spec = SpecFromInspect(ins, postgresImage())
+	if !slices.Equal(spec.Entrypoint, ins.Config.Entrypoint) || !slices.Equal(spec.Command, ins.Config.Cmd) {
+		t.Errorf("Entrypoint, Command = %q, %q, want both kept", spec.Entrypoint, spec.Command)
+	}
+}
+
+func TestSpecFromInspectPortsAndLimits(t *testing.T) {
+	port := network.MustParsePort("80/tcp")
+	pids := int64(200)
+	ins := container.InspectResponse{
+		Name:   "/web",
+		Config: &container.Config{Image: "nginx"},
+		HostConfig: &container.HostConfig{
+			PortBindings: network.PortMap{port: {
+				{HostIP: netip.MustParseAddr("::1"), HostPort: "8080"},
+				{HostIP: netip.MustParseAddr("127.0.0.1"), HostPort: "8081"},
+				{HostIP: netip.MustParseAddr("::"), HostPort: "8082"},
+			}},
+			Resources: container.Resources{
+				Memory:     512 << 20,
+				MemorySwap: 1 << 30,
+				CPUShares:  512,
+				PidsLimit:  &pids,
+			},
+		},
+	}
+
+	spec := SpecFromInspect(ins, nil)
+	// A loopback binding must not come back as one on every interface.
+	if want := []string{"127.0.0.1:8081:80/tcp", "8082:80/tcp", "[::1]:8080:80/tcp"}; !slices.Equal(spec.Ports, want) {
+		t.Errorf("Ports = %q, want %q", spec.Ports, want)
+	}
+	for _, p := range spec.Ports {
+		if _, _, err := parsePortSpec(p); err != nil {
+			t.Errorf("%s: %v", p, err)
+		}
+	}
+	// The default swap, twice the memory, is left to the daemon.
+	if spec.MemorySwap != "" || spec.CPUShares != "512" || spec.PidsLimit != "200" {
+		t.Errorf("MemorySwap, CPUShares, PidsLimit = %q, %q, %q, want \"\", 512, 200", spec.MemorySwap, spec.CPUShares, spec.PidsLimit)
+	}
+	run := spec.RunCommand()
+	for _, want := range []string{"-p '[::1]:8080:80/tcp'", "--cpu-shares 512", "--pids-limit 200"} {
+		if !strings.Contains(run, want) {
+			t.Errorf("run command lacks %s: %s", want, run)
+		}
+	}
+
+	ins.HostConfig.MemorySwap = -1
+	spec = SpecFromInspect(ins, nil)
+	compose := spec.ComposeFile()
+	for _, want := range []string{`memswap_limit: "-1"`, "cpu_shares: 512", "pids_limit: 200"} {
+		if !strings.Contains(compose, "    "+want+"\n") {
+			t.Errorf("compose file lacks %s:\n%s", want, compose)
+		}
+	}
+}
+
+func TestDrift(t *testing.T) {
+	port := network.MustParsePort("80/tcp")
+	ins := container.InspectResponse{
+		Config: &container.Config{Image: "nginx:1.27", Env: []string{"PATH=/usr/bin", "MODE=prod"}},
+		HostConfig: &container.HostConfig{PortBindings: network.PortMap{
+			port: {{HostIP: netip.MustParseAddr("0.0.0.0"), HostPort: "8080"}},
+		}},
+	}
+	spec := CreateSpec{Image: "nginx:1.27", Env: []string{"MODE=prod"}, Ports: []string{"8080:80"}}
+
+	for _, tt := range []struct {
+		edit func(*CreateSpec)
+		want string
+	}{
+		{func(*CreateSpec) {}, ""},
+		{func(s *CreateSpec) { s.Image = "nginx:1.28" }, "image changed"},
+		{func(s *CreateSpec) { s.Env = []string{"MODE=dev"} }, "environment changed"},
+		{func(s *CreateSpec) { s.Ports = []string{"127.0.0.1:8080:80"} }, "ports changed"},
+	} {
+		s := spec
+		tt.edit(&s)
+		got, err := Drift(s, ins)
+		if err != nil || got != tt.want {
+			t.Errorf("%+v: Drift = %q, %v, want %q", s, got, err, tt.want)
+		}
+	}
+}
+
+func TestComposeFileQuotesKeys(t *testing.T) {
+	spec := CreateSpec{
+		Name:   "db",
+		Image:  "postgres:16",
+		Env:    []string{"POSTGRES_PASSWORD=pa$$:word", "yes=no"},
+		Labels: []string{"team=data"},
+	}
+	out := spec.ComposeFile()
+	for _, want := range []string{
+		`      "POSTGRES_PASSWORD": "pa$$$$:word"`,
+		`      "yes": "no"`,
+		`      "team": "data"`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("compose file lacks %s:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+// maxListEntries bounds how many entries ListDir returns. maxListBytes
+// bounds how much of the archive it reads to find them: the archive
+// endpoint always returns the whole subtree, file contents included, so
+// listing / would otherwise stream the entire filesystem and every volume
+// mounted in it.
+const (
+	maxListEntries = 5000
+	maxListBytes   = 32 << 20
+)
+
+// ErrNotDir is returned by ListDir for a path that isn't a directory, nor
+// a symlink to one.
+var ErrNotDir = errors.New("not a directory")
+
+// FileEntry is a single entry of a directory inside a container.
+type FileEntry struct {
+	Name       string
+	Size       int64
+	Mode       os.FileMode
+	ModTime    time.Time
+	LinkTarget string
+}
+
+func (e FileEntry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+func (c *Client) Diff(ctx context.Context, id string) ([]container.FilesystemChange, error) {
+	result, err := c.cli.ContainerDiff(ctx, id, client.ContainerDiffOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return result.Changes, nil
+}
+
+func (c *Client) StatPath(ctx context.Context, id, p string) (container.PathStat, error) {
+	result, err := c.cli.ContainerStatPath(ctx, id, client.ContainerStatPathOptions{Path: p})
+	if err != nil {
+		return container.PathStat{}, err
+	}
+	return result.Stat, nil
+}
+
+// ResolvePath follows p if it is a symlink and returns the path it leads
+// to with its stat. Other paths are returned as they are.
+func (c *Client) ResolvePath(ctx context.Context, id, p string) (string, container.PathStat, error) {
+	stat, err := c.StatPath(ctx, id, p)
+	if err != nil {
+		return "", container.PathStat{}, err
+	}
+	if stat.Mode&os.ModeSymlink == 0 || stat.LinkTarget == "" {
+		return p, stat, nil
+	}
+	// The daemon resolves the target within the container; should it be
+	// relative, it is relative to the link's directory.
+	target := stat.LinkTarget
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(p), target)
+	}
+	stat, err = c.StatPath(ctx, id, target)
+	return target, stat, err
+}
+
+// ListDir returns the direct children of dir, directories first, following
+// dir if it is a symlink. The boolean is true when the listing was cut
+// short by maxListEntries or maxListBytes.
+func (c *Client) ListDir(ctx context.Context, id, dir string) ([]FileEntry, bool, error) {
+	resolved, stat, err := c.ResolvePath(ctx, id, dir)
+	if err != nil {
+		return nil, false, err
+	}
+	if !stat.Mode.IsDir() {
+		return nil, false, fmt.Errorf("%s: %w", dir, ErrNotDir)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	result, err := c.cli.CopyFromContainer(ctx, id, client.CopyFromContainerOptions{SourcePath: resolved})
+	if err != nil {
+		return nil, false, err
+	}
+	defer result.Content.Close()
+
+	// The first header is the directory itself; its children are the
+	// entries sitting exactly one level below it. Deeper entries are
+	// skipped, which on this stream still means reading their contents,
+	// so the whole read is bounded rather than the entries.
+	var entries []FileEntry
+	root := ""
+	content := &io.LimitedReader{R: result.Content, N: maxListBytes}
+	tr := tar.NewReader(content)
+	for i := 0; ; i++ {
+		if len(entries) >= maxListEntries {
+			sortEntries(entries)
+			return entries, true, nil
+		}
+		hdr, err := tr.Next()
+		if content.N <= 0 {
+			sortEntries(entries)
+			return entries, true, nil
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		name := path.Clean(hdr.Name)
+		if i == 0 {
+			root = name
+			continue
+		}
+		if path.Dir(name) != root {
+			continue
+		}
+		entries = append(entries, FileEntry{
+			Name:       path.Base(name),
+			Size:       hdr.Size,
+			Mode:       hdr.FileInfo().Mode(),
+			ModTime:    hdr.ModTime,
+			LinkTarget: hdr.Linkname,
+		})
+	}
+
+	sortEntries(entries)
+	return entries, false, nil
+}
+
+// ReadFile returns at most limit bytes of the file at p. The boolean is
+// true when the file is larger than limit.
+func (c *Client) ReadFile(ctx context.Context, id, p string, limit int64) ([]byte, bool, error) {
+	result, err := c.cli.CopyFromContainer(ctx, id, client.CopyFromContainerOptions{SourcePath: p})
+	if err != nil {
+		return nil, false, err
+	}
+	defer result.Content.Close()
+
+	if result.Stat.Mode.IsDir() {
+		return nil, false, errors.New(p + " is a directory")
+	}
+
+	tr := tar.NewReader(result.Content)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := io.ReadAll(io.LimitReader(tr, limit))
+	if err != nil {
+		return nil, false, err
+	}
+	return data, hdr.Size > limit, nil
+}
+
+// Download writes p, file or directory, to w as a tar archive exactly as
+// docker cp would fetch it.
+func (c *Client) Download(ctx context.Context, id, p string, w io.Writer) (int64, error) {
+	result, err := c.cli.CopyFromContainer(ctx, id, client.CopyFromContainerOptions{SourcePath: p})
+	if err != nil {
+		return 0, err
+	}
+	defer result.Content.Close()
+	return io.Copy(w, result.Content)
+}
+
+func sortEntries(entries []FileEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
@@ -0,0 +1,152 @@
+package docker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/distribution/reference"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+// imageCheckConcurrency bounds the inspect and registry calls of CheckImages.
+const imageCheckConcurrency = 8
+
+type ImageState int
+
+const (
+	// ImageUnknown covers images that can't be compared: built locally,
+	// pinned by digest, or a registry that couldn't be reached.
+	ImageUnknown ImageState = iota
+	ImageCurrent
+	ImageOutdated
+)
+
+// ImageCheck is the result of comparing a container's image with its
+// registry.
+type ImageCheck struct {
+	// Image is the reference the container was created from.
+	Image string
+	State ImageState
+	// Remote is the digest the registry currently serves for Image.
+	Remote string
+	// Reason explains an ImageUnknown state.
+	Reason string
+}
+
+// CheckImages compares the image of every container with the digest the
+// registry serves for the same tag. The result is keyed by container ID.
+//
+// The local side is the image's RepoDigests: the manifest digests it was
+// pulled as. ImageID can't be used directly, it is the digest of the image
+// config rather than of the manifest the registry hands out.
+func (c *Client) CheckImages(ctx context.Context, containers []container.Summary) map[string]ImageCheck {
+	var (
+		mu      sync.Mutex
+		results = make(map[string]ImageCheck, len(containers))
+		remotes = newDigestCache()
+		sem     = make(chan struct{}, imageCheckConcurrency)
+		wg      sync.WaitGroup
+	)
+	for _, ctr := range containers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			check := c.checkImage(ctx, ctr.ID, remotes)
+			mu.Lock()
+			results[ctr.ID] = check
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (c *Client) checkImage(ctx context.Context, id string, remotes *digestCache) ImageCheck {
+	ins, err := c.Inspect(ctx, id)
+	if err != nil || ins.Config == nil {
+		return ImageCheck{Reason: "container could not be inspected"}
+	}
+	check := ImageCheck{Image: ins.Config.Image}
+
+	named, err := reference.ParseNormalizedNamed(ins.Config.Image)
+	if err != nil {
+		check.Reason = "created from an image ID"
+		return check
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		check.Reason = "pinned by digest"
+		return check
+	}
+	named = reference.TagNameOnly(named)
+
+	remote, err := remotes.get(named.String(), func() (string, error) {
+		result, err := c.cli.DistributionInspect(ctx, named.String(), client.DistributionInspectOptions{})
+		if err != nil {
+			return "", err
+		}
+		return result.Descriptor.Digest.String(), nil
+	})
+	if err != nil {
+		check.Reason = "registry: " + err.Error()
+		return check
+	}
+	check.Remote = remote
+
+	img, err := c.cli.ImageInspect(ctx, ins.Image)
+	if err != nil {
+		check.Reason = "image: " + err.Error()
+		return check
+	}
+	pulled := false
+	for _, rd := range img.RepoDigests {
+		local, err := reference.ParseNormalizedNamed(rd)
+		if err != nil || local.Name() != named.Name() {
+			continue
+		}
+		pulled = true
+		if digested, ok := local.(reference.Digested); ok && digested.Digest().String() == remote {
+			check.State = ImageCurrent
+			return check
+		}
+	}
+	if !pulled {
+		check.Reason = "not pulled from " + reference.Domain(named)
+		return check
+	}
+	check.State = ImageOutdated
+	return check
+}
+
+// digestCache asks the registry once per reference, however many
+// containers share it.
+type digestCache struct {
+	mu      sync.Mutex
+	entries map[string]*digestEntry
+}
+
+type digestEntry struct {
+	once   sync.Once
+	digest string
+	err    error
+}
+
+func newDigestCache() *digestCache {
+	return &digestCache{entries: map[string]*digestEntry{}}
+}
+
+func (dc *digestCache) get(ref string, fetch func() (string, error)) (string, error) {
+	dc.mu.Lock()
+	e, ok := dc.entries[ref]
+	if !ok {
+		e = &digestEntry{}
+		dc.entries[ref] = e
+	}
+	dc.mu.Unlock()
+
+	e.once.Do(func() { e.digest, e.err = fetch() })
+	return e.digest, e.err
+}
@@ -0,0 +1,145 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+const (
+	digestCurrent = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	digestOld     = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	imageID       = "3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+// fakeDaemon answers the inspect and distribution calls CheckImages makes,
+// standing in for both the daemon and the registry behind it. containers
+// maps a container ID to the image it was created from, images an image ID
+// to its RepoDigests, registry a tagged reference to the digest it serves.
+type fakeDaemon struct {
+	containers    map[string][2]string // ID -> {Config.Image, Image}
+	images        map[string][]string
+	registry      map[string]string
+	registryCalls atomic.Int32
+}
+
+func (d *fakeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Api-Version", "1.52")
+	// Drop the /v1.xx version prefix.
+	path := r.URL.Path
+	if strings.HasPrefix(path, "/v1.") {
+		path = path[strings.Index(path[1:], "/")+1:]
+	}
+	switch {
+	case path == "/_ping":
+		w.Write([]byte("OK"))
+	case strings.HasPrefix(path, "/containers/"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/json")
+		c, ok := d.containers[id]
+		if !ok {
+			http.Error(w, `{"message":"no such container"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Id": id, "Image": c[1], "Config": map[string]any{"Image": c[0]}})
+	case strings.HasPrefix(path, "/images/"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json")
+		digests, ok := d.images[id]
+		if !ok {
+			http.Error(w, `{"message":"no such image"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Id": id, "RepoDigests": digests})
+	case strings.HasPrefix(path, "/distribution/"):
+		d.registryCalls.Add(1)
+		ref := strings.TrimSuffix(strings.TrimPrefix(path, "/distribution/"), "/json")
+		digest, ok := d.registry[ref]
+		if !ok {
+			http.Error(w, `{"message":"manifest unknown"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Descriptor": map[string]any{
+			"mediaType": "application/vnd.oci.image.index.v1+json",
+			"digest":    digest,
+			"size":      1,
+		}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestCheckImages(t *testing.T) {
+	d := &fakeDaemon{
+		containers: map[string][2]string{
+			"current":   {"localhost:5000/app:1", "sha256:img-current"},
+			"current2":  {"localhost:5000/app:1", "sha256:img-current"},
+			"outdated":  {"localhost:5000/app:2", "sha256:img-old"},
+			"latest":    {"localhost:5000/app", "sha256:img-latest"},
+			"built":     {"localhost:5000/app:1", "sha256:img-built"},
+			"pinned":    {"localhost:5000/app@" + digestCurrent, "sha256:img-current"},
+			"fromID":    {imageID, "sha256:" + imageID},
+			"unreached": {"localhost:5000/gone:1", "sha256:img-current"},
+		},
+		images: map[string][]string{
+			"sha256:img-current": {"localhost:5000/app@" + digestCurrent},
+			// Pulled from another registry too, only the same repository counts.
+			"sha256:img-old":    {"docker.io/library/app@" + digestCurrent, "localhost:5000/app@" + digestOld},
+			"sha256:img-latest": {"localhost:5000/app@" + digestCurrent},
+			"sha256:img-built":  nil,
+		},
+		registry: map[string]string{
+			"localhost:5000/app:1":      digestCurrent,
+			"localhost:5000/app:2":      digestCurrent,
+			"localhost:5000/app:latest": digestCurrent,
+		},
+	}
+	srv := httptest.NewServer(d)
+	defer srv.Close()
+
+	cli, err := client.New(client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")), client.WithAPIVersion("1.52"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{cli: cli}
+	defer c.Close()
+
+	var containers []container.Summary
+	for id := range d.containers {
+		containers = append(containers, container.Summary{ID: id})
+	}
+	got := c.CheckImages(context.Background(), containers)
+
+	want := map[string]ImageState{
+		"current":   ImageCurrent,
+		"current2":  ImageCurrent,
+		"outdated":  ImageOutdated,
+		"latest":    ImageCurrent,
+		"built":     ImageUnknown,
+		"pinned":    ImageUnknown,
+		"fromID":    ImageUnknown,
+		"unreached": ImageUnknown,
+	}
+	for id, state := range want {
+		check := got[id]
+		if check.State != state {
+			t.Errorf("%s: State = %v, want %v (reason %q)", id, check.State, state, check.Reason)
+		}
+		if state == ImageUnknown && check.Reason == "" {
+			t.Errorf("%s: no reason given for an unknown state", id)
+		}
+	}
+	if got["outdated"].Remote != digestCurrent {
+		t.Errorf("outdated: Remote = %q, want %q", got["outdated"].Remote, digestCurrent)
+	}
+
+	// app:1 is shared by three containers but asked for once.
+	if n := d.registryCalls.Load(); n != 4 {
+		t.Errorf("registry asked %d times, want once per tag (4)", n)
+	}
+}
@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"context"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/moby/client"
+)
+
+// EnsureNetwork creates a bridge network with the given labels unless one
+// with that name already exists. It reports whether it created it.
+func (c *Client) EnsureNetwork(ctx context.Context, name string, labels map[string]string) (bool, error) {
+	_, err := c.cli.NetworkInspect(ctx, name, client.NetworkInspectOptions{})
+	if err == nil {
+		return false, nil
+	}
+	if !cerrdefs.IsNotFound(err) {
+		return false, err
+	}
+	err = c.audited(ctx, "", "network-create", map[string]any{"network": name}, func() error {
+		_, err := c.cli.NetworkCreate(ctx, name, client.NetworkCreateOptions{Driver: "bridge", Labels: labels})
+		return err
+	})
+	return err == nil, err
+}
+
+// RemoveNetwork deletes a network. A network that is already gone is not an
+// error.
+func (c *Client) RemoveNetwork(ctx context.Context, name string) error {
+	err := c.audited(ctx, "", "network-remove", map[string]any{"network": name}, func() error {
+		_, err := c.cli.NetworkRemove(ctx, name, client.NetworkRemoveOptions{})
+		return err
+	})
+	if cerrdefs.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// EnsureVolume creates a named volume with the given labels unless it
+// already exists. It reports whether it created it.
+func (c *Client) EnsureVolume(ctx context.Context, name string, labels map[string]string) (bool, error) {
+	_, err := c.cli.VolumeInspect(ctx, name, client.VolumeInspectOptions{})
+	if err == nil {
+		return false, nil
+	}
+	if !cerrdefs.IsNotFound(err) {
+		return false, err
+	}
+	err = c.audited(ctx, "", "volume-create", map[string]any{"volume": name}, func() error {
+		_, err := c.cli.VolumeCreate(ctx, client.VolumeCreateOptions{Name: name, Labels: labels})
+		return err
+	})
+	return err == nil, err
+}
+
+// RemoveVolume deletes a named volume. A volume that is already gone is not
+// an error.
+func (c *Client) RemoveVolume(ctx context.Context, name string) error {
+	err := c.audited(ctx, "", "volume-remove", map[string]any{"volume": name}, func() error {
+		_, err := c.cli.VolumeRemove(ctx, name, client.VolumeRemoveOptions{})
+		return err
+	})
+	if cerrdefs.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+// PublishedPort is a container port bound on the Docker host.
+type PublishedPort struct {
+	ContainerPort uint16
+	Proto         string // "tcp", "udp" or "sctp"
+	HostPort      string
+	// Host is where the port is reached from here: the bound address, or
+	// the daemon's host when bound to all addresses.
+	Host string
+}
+
+// Address is the host:port to dial.
+func (p PublishedPort) Address() string {
+	return net.JoinHostPort(p.Host, p.HostPort)
+}
+
+// URL guesses the URL served on the port: https for the usual TLS ports,
+// http otherwise. It is empty for anything but TCP.
+func (p PublishedPort) URL() string {
+	if p.Proto != "tcp" {
+		return ""
+	}
+	scheme := "http"
+	switch p.ContainerPort {
+	case 443, 8443:
+		scheme = "https"
+	}
+	return scheme + "://" + p.Address()
+}
+
+func (p PublishedPort) String() string {
+	return fmt.Sprintf("%s:%d/%s", p.HostPort, p.ContainerPort, p.Proto)
+}
+
+// PublishedPorts lists the ports of ins bound on the host, ordered by
+// container port. The IPv4 and IPv6 bindings the daemon makes for one
+// published port are reported once.
+func (c *Client) PublishedPorts(ins container.InspectResponse) []PublishedPort {
+	if ins.NetworkSettings == nil {
+		return nil
+	}
+	host := c.daemonHost()
+	var out []PublishedPort
+	seen := map[string]bool{}
+	for port, bindings := range ins.NetworkSettings.Ports {
+		for _, b := range bindings {
+			if b.HostPort == "" {
+				continue
+			}
+			p := PublishedPort{
+				ContainerPort: port.Num(),
+				Proto:         string(port.Proto()),
+				HostPort:      b.HostPort,
+				Host:          host,
+			}
+			if b.HostIP.IsValid() && !b.HostIP.IsUnspecified() {
+				p.Host = b.HostIP.String()
+			}
+			if key := p.Proto + " " + p.Address(); !seen[key] {
+				seen[key] = true
+				out = append(out, p)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ContainerPort != out[j].ContainerPort {
+			return out[i].ContainerPort < out[j].ContainerPort
+		}
+		return out[i].Address() < out[j].Address()
+	})
+	return out
+}
+
+// daemonHost is the host ports published on all addresses are reached at:
+// the daemon's when it is remote (tcp:// or ssh:// DOCKER_HOST), localhost
+// for a local socket.
+func (c *Client) daemonHost() string {
+	u, err := url.Parse(c.cli.DaemonHost())
+	if err != nil {
+		return "localhost"
+	}
+	switch u.Scheme {
+	case "tcp", "http", "https", "ssh":
+		if h := u.Hostname(); h != "" {
+			return h
+		}
+	}
+	return "localhost"
+}
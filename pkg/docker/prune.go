@@ -0,0 +1,259 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/client"
+)
+
+// Image and volume selections for PruneOptions.
+const (
+	PruneDangling  = "dangling"  // untagged images
+	PruneUnused    = "unused"    // images no container uses
+	PruneAnonymous = "anonymous" // volumes created without a name
+	PruneAll       = "all"       // every volume no container uses
+)
+
+// anonymousVolumeLabel is set by the daemon on volumes it names itself.
+const anonymousVolumeLabel = "com.docker.volume.anonymous"
+
+// predefinedNetworks can't be removed.
+var predefinedNetworks = map[string]bool{"bridge": true, "host": true, "none": true}
+
+// PruneOptions selects what PlanPrune looks for.
+type PruneOptions struct {
+	Containers bool
+	// OlderThan keeps stopped containers created more recently, as the
+	// until filter of docker container prune does. Zero takes them all.
+	OlderThan  time.Duration
+	Images     string // "", PruneDangling or PruneUnused
+	Volumes    string // "", PruneAnonymous or PruneAll
+	Networks   bool
+	BuildCache bool
+}
+
+// PruneItem is one object a prune removes. Size is the space it frees, 0
+// when unknown. Err is filled in by ExecutePrune.
+type PruneItem struct {
+	Kind PruneKind
+	ID   string
+	Name string
+	Size int64
+	Err  error
+}
+
+// PlanPrune lists what a prune with opts would remove, without removing
+// anything. An object counts as used when a container that survives the
+// prune uses it, so images, volumes and networks only held by the stopped
+// containers being pruned go in the same run.
+func (c *Client) PlanPrune(ctx context.Context, opts PruneOptions) ([]PruneItem, error) {
+	containers, err := c.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	du, err := c.cli.DiskUsage(ctx, client.DiskUsageOptions{
+		Containers: opts.Containers,
+		Images:     opts.Images != "",
+		Volumes:    opts.Volumes != "",
+		BuildCache: opts.BuildCache,
+		Verbose:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []PruneItem
+	removed := map[string]bool{}
+	if opts.Containers {
+		sizes := map[string]int64{}
+		for _, s := range du.Containers.Items {
+			sizes[s.ID] = s.SizeRw
+		}
+		now := time.Now()
+		for _, ctr := range containers {
+			switch ctr.State {
+			case container.StateExited, container.StateCreated, container.StateDead:
+			default:
+				continue
+			}
+			if opts.OlderThan > 0 && now.Sub(time.Unix(ctr.Created, 0)) < opts.OlderThan {
+				continue
+			}
+			removed[ctr.ID] = true
+			items = append(items, PruneItem{Kind: PruneContainers, ID: ctr.ID, Name: containerName(ctr), Size: sizes[ctr.ID]})
+		}
+	}
+
+	usedImages, usedVolumes, usedNetworks := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, ctr := range containers {
+		if removed[ctr.ID] {
+			continue
+		}
+		usedImages[ctr.ImageID] = true
+		for _, m := range ctr.Mounts {
+			if m.Type == mount.TypeVolume {
+				usedVolumes[m.Name] = true
+			}
+		}
+		if ns := ctr.NetworkSettings; ns != nil {
+			for name := range ns.Networks {
+				usedNetworks[name] = true
+			}
+		}
+		usedNetworks[ctr.HostConfig.NetworkMode] = true
+	}
+
+	if opts.Images != "" {
+		for _, img := range du.Images.Items {
+			if usedImages[img.ID] {
+				continue
+			}
+			tags := realTags(img.RepoTags)
+			if opts.Images == PruneDangling && len(tags) > 0 {
+				continue
+			}
+			size := img.Size
+			if img.SharedSize > 0 {
+				size -= img.SharedSize
+			}
+			name := strings.Join(tags, ", ")
+			if name == "" {
+				name = "<none>"
+			}
+			items = append(items, PruneItem{Kind: PruneImages, ID: img.ID, Name: name, Size: size})
+		}
+	}
+
+	if opts.Volumes != "" {
+		for _, v := range du.Volumes.Items {
+			if usedVolumes[v.Name] {
+				continue
+			}
+			if _, anonymous := v.Labels[anonymousVolumeLabel]; opts.Volumes == PruneAnonymous && !anonymous {
+				continue
+			}
+			var size int64
+			if v.UsageData != nil && v.UsageData.Size > 0 {
+				size = v.UsageData.Size
+			}
+			items = append(items, PruneItem{Kind: PruneVolumes, ID: v.Name, Name: v.Name, Size: size})
+		}
+	}
+
+	if opts.Networks {
+		nets, err := c.cli.NetworkList(ctx, client.NetworkListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nets.Items {
+			if predefinedNetworks[n.Name] || n.Scope != "local" || usedNetworks[n.Name] {
+				continue
+			}
+			items = append(items, PruneItem{Kind: PruneNetworks, ID: n.ID, Name: n.Name})
+		}
+	}
+
+	if opts.BuildCache {
+		for _, r := range du.BuildCache.Items {
+			if r.InUse {
+				continue
+			}
+			item := PruneItem{Kind: PruneBuildCache, ID: r.ID, Name: r.Description}
+			if !r.Shared {
+				item.Size = r.Size
+			}
+			items = append(items, item)
+		}
+	}
+
+	// Containers go first so nothing else is still in use when its turn
+	// comes; within a kind, the biggest first.
+	rank := map[PruneKind]int{PruneContainers: 0, PruneImages: 1, PruneVolumes: 2, PruneNetworks: 3, PruneBuildCache: 4}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return rank[items[i].Kind] < rank[items[j].Kind]
+		}
+		return items[i].Size > items[j].Size
+	})
+	return items, nil
+}
+
+// ExecutePrune removes exactly the planned items, one by one, and returns
+// them with the outcome of each in Err. Something created since the plan
+// is never touched; something that became used fails on its own without
+// stopping the rest. progress, if set, is called after each item.
+func (c *Client) ExecutePrune(ctx context.Context, items []PruneItem, progress func(done int)) []PruneItem {
+	out := make([]PruneItem, len(items))
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			item.Err = err
+		} else {
+			item.Err = c.record(item.ID, item.Name, "prune", map[string]any{"kind": string(item.Kind)}, c.pruneItem(ctx, &item))
+		}
+		out[i] = item
+		if progress != nil {
+			progress(i + 1)
+		}
+	}
+	return out
+}
+
+func (c *Client) pruneItem(ctx context.Context, item *PruneItem) error {
+	switch item.Kind {
+	case PruneContainers:
+		return c.remove(ctx, item.ID)
+	case PruneImages:
+		// Removing by ID fails for images with several tags, untag them
+		// one by one instead; the last one deletes the image.
+		refs := strings.Split(item.Name, ", ")
+		if item.Name == "<none>" {
+			refs = []string{item.ID}
+		}
+		for _, ref := range refs {
+			if _, err := c.cli.ImageRemove(ctx, ref, client.ImageRemoveOptions{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case PruneVolumes:
+		_, err := c.cli.VolumeRemove(ctx, item.ID, client.VolumeRemoveOptions{})
+		return err
+	case PruneNetworks:
+		_, err := c.cli.NetworkRemove(ctx, item.ID, client.NetworkRemoveOptions{})
+		return err
+	case PruneBuildCache:
+		r, err := c.cli.BuildCachePrune(ctx, client.BuildCachePruneOptions{
+			All:     true,
+			Filters: make(client.Filters).Add("id", item.ID),
+		})
+		if err == nil {
+			item.Size = int64(r.Report.SpaceReclaimed)
+		}
+		return err
+	}
+	return fmt.Errorf("unknown prune kind %q", item.Kind)
+}
+
+// realTags drops the <none>:<none> placeholder of untagged images.
+func realTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		if t != "<none>:<none>" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func containerName(c container.Summary) string {
+	if len(c.Names) > 0 {
+		return strings.TrimPrefix(c.Names[0], "/")
+	}
+	return c.ID[:12]
+}
@@ -0,0 +1,413 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+)
+
+const (
+	// healthTimeout bounds how long Recreate waits for a healthcheck to pass.
+	healthTimeout = 2 * time.Minute
+	// settleTime is how long a container without healthcheck must stay
+	// running before it is considered started.
+	settleTime = 5 * time.Second
+)
+
+func (c *Client) Rename(ctx context.Context, id, name string) error {
+	return c.audited(ctx, id, "rename", map[string]any{"name": name}, func() error {
+		return c.rename(ctx, id, name)
+	})
+}
+
+func (c *Client) rename(ctx context.Context, id, name string) error {
+	_, err := c.cli.ContainerRename(ctx, id, client.ContainerRenameOptions{NewName: name})
+	return err
+}
+
+// Recreate replaces the container id with a new one built from spec. The
+// old container is stopped and kept under a backup name until the new one
+// is up (healthy if it has a healthcheck), then removed. On any failure the
+// new container is discarded and the old one restored and restarted.
+func (c *Client) Recreate(ctx context.Context, id string, spec CreateSpec) (string, error) {
+	var newID string
+	err := c.audited(ctx, id, "recreate", spec.auditParams(), func() error {
+		var err error
+		newID, err = c.recreate(ctx, id, spec)
+		return err
+	})
+	return newID, err
+}
+
+func (c *Client) recreate(ctx context.Context, id string, spec CreateSpec) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+	old, err := c.Inspect(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if spec.Name == "" {
+		spec.Name = strings.TrimPrefix(old.Name, "/")
+	}
+	return c.replace(ctx, old, func() (string, error) {
+		return c.create(ctx, spec)
+	})
+}
+
+// Reconfigure is Recreate for a change to a container rather than a new
+// definition of it. spec is what SpecFromInspect renders of the container,
+// edited: only the image, env, ports, memory, CPUs and restart policy that
+// differ from it are applied. Everything else the container was created
+// with, its user, capabilities, devices, DNS, logging, static IPs and the
+// like, is carried over from its inspect as is.
+func (c *Client) Reconfigure(ctx context.Context, id string, spec CreateSpec) (string, error) {
+	var newID string
+	err := c.audited(ctx, id, "recreate", spec.auditParams(), func() error {
+		var err error
+		newID, err = c.reconfigure(ctx, id, spec)
+		return err
+	})
+	return newID, err
+}
+
+func (c *Client) reconfigure(ctx context.Context, id string, spec CreateSpec) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+	old, err := c.Inspect(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	img, err := c.ImageConfig(ctx, old)
+	if err != nil {
+		return "", err
+	}
+	opts, networks, err := reconfigureOptions(old, img, spec)
+	if err != nil {
+		return "", err
+	}
+	return c.replace(ctx, old, func() (string, error) {
+		return c.createWith(ctx, opts, networks)
+	})
+}
+
+// replace swaps the container old for the one create makes, see Recreate.
+func (c *Client) replace(ctx context.Context, old container.InspectResponse, create func() (string, error)) (string, error) {
+	id := old.ID
+	name := strings.TrimPrefix(old.Name, "/")
+	backup := fmt.Sprintf("%s-stackr-backup-%d", name, time.Now().Unix())
+	wasRunning := old.State != nil && old.State.Running
+
+	if err := c.stop(ctx, id); err != nil {
+		return "", fmt.Errorf("stop: %w", err)
+	}
+	if err := c.rename(ctx, id, backup); err != nil {
+		return "", c.rollback(ctx, id, name, "", wasRunning, fmt.Errorf("rename to backup: %w", err))
+	}
+
+	newID, err := create()
+	if err != nil {
+		return "", c.rollback(ctx, id, name, newID, wasRunning, fmt.Errorf("create: %w", err))
+	}
+	if err := c.start(ctx, newID); err != nil {
+		return "", c.rollback(ctx, id, name, newID, wasRunning, fmt.Errorf("start: %w", err))
+	}
+	if err := c.WaitStarted(ctx, newID); err != nil {
+		return "", c.rollback(ctx, id, name, newID, wasRunning, err)
+	}
+
+	if err := c.remove(ctx, id); err != nil {
+		return newID, fmt.Errorf("new container is up but backup %s could not be removed: %w", backup, err)
+	}
+	return newID, nil
+}
+
+// reconfigureOptions turns the inspect of a container back into the options
+// it was created with, less what it inherited from its image img, and
+// applies the fields of spec that differ from SpecFromInspect. Endpoints
+// other than the network mode's come back apart, see createWith.
+func reconfigureOptions(ins container.InspectResponse, img *dockerspec.DockerOCIImageConfig, spec CreateSpec) (client.ContainerCreateOptions, map[string]*network.EndpointSettings, error) {
+	if ins.Config == nil || ins.HostConfig == nil {
+		return client.ContainerCreateOptions{}, nil, fmt.Errorf("inspect of %.12s has no config", ins.ID)
+	}
+	cur := SpecFromInspect(ins, img)
+	cfg := *ins.Config
+	host := *ins.HostConfig
+	withoutImageDefaults(&cfg, img)
+	cfg.Image = strings.TrimSpace(spec.Image)
+	// The daemon names a container's host after its ID unless told
+	// otherwise, the new one gets its own.
+	if len(ins.ID) >= 12 && cfg.Hostname == ins.ID[:12] {
+		cfg.Hostname = ""
+	}
+
+	if !slices.Equal(spec.Env, cur.Env) {
+		cfg.Env = spec.Env
+	}
+
+	if !slices.Equal(spec.Ports, cur.Ports) {
+		exposed := network.PortSet{}
+		for port := range cfg.ExposedPorts {
+			if _, published := host.PortBindings[port]; !published {
+				exposed[port] = struct{}{}
+			}
+		}
+		bindings := network.PortMap{}
+		for _, p := range spec.Ports {
+			port, binding, err := parsePortSpec(p)
+			if err != nil {
+				return client.ContainerCreateOptions{}, nil, err
+			}
+			exposed[port] = struct{}{}
+			bindings[port] = append(bindings[port], *binding)
+		}
+		cfg.ExposedPorts, host.PortBindings = exposed, bindings
+	}
+
+	if spec.Memory != cur.Memory {
+		var mem int64
+		if spec.Memory != "" {
+			var err error
+			if mem, err = units.RAMInBytes(spec.Memory); err != nil {
+				return client.ContainerCreateOptions{}, nil, fmt.Errorf("invalid memory %q: %w", spec.Memory, err)
+			}
+		}
+		host.MemorySwap = scaleSwap(host.Memory, host.MemorySwap, mem)
+		host.Memory = mem
+	}
+
+	if spec.CPUs != cur.CPUs {
+		host.NanoCPUs = 0
+		if spec.CPUs != "" {
+			cpus, err := strconv.ParseFloat(spec.CPUs, 64)
+			if err != nil || cpus <= 0 {
+				return client.ContainerCreateOptions{}, nil, fmt.Errorf("invalid cpus %q: expected a positive number", spec.CPUs)
+			}
+			host.NanoCPUs = int64(cpus * 1e9)
+		}
+	}
+
+	if spec.Restart != cur.Restart {
+		host.RestartPolicy = container.RestartPolicy{Name: container.RestartPolicyDisabled}
+		if spec.Restart != "" {
+			policy, err := ParseRestartPolicy(spec.Restart)
+			if err != nil {
+				return client.ContainerCreateOptions{}, nil, err
+			}
+			host.RestartPolicy = policy
+		}
+	}
+
+	// Anonymous volumes are not in the host config, the new container
+	// would get empty ones in their place.
+	for _, m := range ins.Mounts {
+		if m.Type == mount.TypeVolume && m.Name != "" && !mounted(&host, m.Destination) {
+			v := m.Name + ":" + m.Destination
+			if !m.RW {
+				v += ":ro"
+			}
+			host.Binds = append(slices.Clone(host.Binds), v)
+		}
+	}
+
+	opts := client.ContainerCreateOptions{
+		Config:     &cfg,
+		HostConfig: &host,
+		Name:       strings.TrimPrefix(ins.Name, "/"),
+	}
+	mode := host.NetworkMode
+	if ins.NetworkSettings == nil || !(mode.IsDefault() || mode.IsBridge() || mode.IsUserDefined()) {
+		return opts, nil, nil
+	}
+	networks := map[string]*network.EndpointSettings{}
+	for name, ep := range ins.NetworkSettings.Networks {
+		if ep == nil {
+			continue
+		}
+		// Only what the container was configured with, the addresses it
+		// got handed are the daemon's to give again.
+		networks[name] = &network.EndpointSettings{
+			IPAMConfig: ep.IPAMConfig,
+			Links:      ep.Links,
+			Aliases:    ep.Aliases,
+			DriverOpts: ep.DriverOpts,
+			GwPriority: ep.GwPriority,
+		}
+	}
+	primary := mode.NetworkName()
+	if mode.IsDefault() {
+		primary = network.NetworkBridge
+	}
+	if ep, ok := networks[primary]; ok {
+		opts.NetworkingConfig = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{primary: ep}}
+		delete(networks, primary)
+	}
+	return opts, networks, nil
+}
+
+// withoutImageDefaults drops from cfg what the container inherited from its
+// image img, so an updated image brings its own.
+func withoutImageDefaults(cfg *container.Config, img *dockerspec.DockerOCIImageConfig) {
+	if img == nil {
+		return
+	}
+	cfg.Env = slices.DeleteFunc(slices.Clone(cfg.Env), func(e string) bool {
+		return slices.Contains(img.Env, e)
+	})
+	// Setting an entrypoint drops the image's command, so the command is
+	// only inherited along with the entrypoint.
+	if slices.Equal(cfg.Entrypoint, img.Entrypoint) {
+		cfg.Entrypoint = nil
+		if slices.Equal(cfg.Cmd, img.Cmd) {
+			cfg.Cmd = nil
+		}
+	}
+	if sameHealth(cfg.Healthcheck, img.Healthcheck) {
+		cfg.Healthcheck = nil
+	}
+	if cfg.Labels != nil {
+		cfg.Labels = maps.Clone(cfg.Labels)
+		maps.DeleteFunc(cfg.Labels, func(k, v string) bool {
+			iv, ok := img.Labels[k]
+			return ok && iv == v
+		})
+	}
+	if cfg.ExposedPorts != nil {
+		cfg.ExposedPorts = maps.Clone(cfg.ExposedPorts)
+		for p := range img.ExposedPorts {
+			if port, err := network.ParsePort(p); err == nil {
+				delete(cfg.ExposedPorts, port)
+			}
+		}
+	}
+	if cfg.Volumes != nil {
+		cfg.Volumes = maps.Clone(cfg.Volumes)
+		for v := range img.Volumes {
+			delete(cfg.Volumes, v)
+		}
+	}
+	if cfg.User == img.User {
+		cfg.User = ""
+	}
+	if cfg.WorkingDir == img.WorkingDir {
+		cfg.WorkingDir = ""
+	}
+	if cfg.StopSignal == img.StopSignal {
+		cfg.StopSignal = ""
+	}
+	if slices.Equal(cfg.Shell, img.Shell) {
+		cfg.Shell = nil
+	}
+	cfg.OnBuild = nil
+}
+
+// mounted reports whether the host config mounts something at target.
+func mounted(host *container.HostConfig, target string) bool {
+	for _, b := range host.Binds {
+		if parts := strings.Split(b, ":"); len(parts) > 1 && parts[1] == target {
+			return true
+		}
+	}
+	for _, m := range host.Mounts {
+		if m.Target == target {
+			return true
+		}
+	}
+	return false
+}
+
+// scaleSwap is the swap limit that keeps the ratio of swap to memory when
+// the memory limit goes from mem to newMem. Unlimited swap stays unlimited
+// and no limit lets the daemon pick its default.
+func scaleSwap(mem, swap, newMem int64) int64 {
+	switch {
+	case swap < 0:
+		return swap
+	case newMem == 0 || mem == 0 || swap == 0:
+		return 0
+	}
+	return int64(float64(swap) / float64(mem) * float64(newMem))
+}
+
+// rollback undoes a failed Recreate and returns cause, annotated with any
+// error hit while restoring.
+func (c *Client) rollback(ctx context.Context, oldID, name, newID string, restart bool, cause error) error {
+	var errs []error
+	if newID != "" {
+		if _, err := c.cli.ContainerRemove(ctx, newID, client.ContainerRemoveOptions{Force: true}); err != nil {
+			errs = append(errs, fmt.Errorf("remove new container: %w", err))
+		}
+	}
+	if err := c.rename(ctx, oldID, name); err != nil {
+		errs = append(errs, fmt.Errorf("restore name: %w", err))
+	}
+	if restart {
+		if err := c.start(ctx, oldID); err != nil {
+			errs = append(errs, fmt.Errorf("restart old container: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w (rollback failed: %w)", cause, errors.Join(errs...))
+	}
+	return fmt.Errorf("%w (rolled back)", cause)
+}
+
+// WaitStarted blocks until the container is healthy, or has stayed running
+// for a few seconds when it has no healthcheck.
+func (c *Client) WaitStarted(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	var runningSince time.Time
+	for {
+		ins, err := c.Inspect(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		st := ins.State
+		switch {
+		case st == nil:
+		case st.Status == container.StateExited || st.Status == container.StateDead:
+			return fmt.Errorf("container exited with code %d", st.ExitCode)
+		case st.Health != nil:
+			switch st.Health.Status {
+			case container.Healthy:
+				return nil
+			case container.Unhealthy:
+				return fmt.Errorf("container is unhealthy")
+			}
+		case st.Running && !st.Restarting:
+			if runningSince.IsZero() {
+				runningSince = time.Now()
+			}
+			if time.Since(runningSince) >= settleTime {
+				return nil
+			}
+		default:
+			runningSince = time.Time{}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for container to start: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package docker
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/network"
+)
+
+func dbInspect() container.InspectResponse {
+	port := network.MustParsePort("5432/tcp")
+	return container.InspectResponse{
+		ID:   "0123456789abcdef0123456789abcdef",
+		Name: "/db",
+		Config: &container.Config{
+			Hostname:     "0123456789ab",
+			Image:        "postgres:15",
+			User:         "999",
+			Env:          []string{"PATH=/usr/local/bin:/usr/bin", "PG_MAJOR=15", "POSTGRES_PASSWORD=secret"},
+			Entrypoint:   []string{"docker-entrypoint.sh"},
+			Cmd:          []string{"postgres"},
+			Labels:       map[string]string{"maintainer": "postgres", "team": "data"},
+			Healthcheck:  &container.HealthConfig{Test: []string{"CMD", "pg_isready"}},
+			ExposedPorts: network.PortSet{port: {}},
+		},
+		HostConfig: &container.HostConfig{
+			NetworkMode:  "backend",
+			Privileged:   true,
+			CapAdd:       []string{"NET_ADMIN"},
+			DNS:          []netip.Addr{netip.MustParseAddr("10.0.0.53")},
+			ShmSize:      256 << 20,
+			Binds:        []string{"pgdata:/var/lib/postgresql/data"},
+			PortBindings: network.PortMap{port: {{HostIP: netip.MustParseAddr("::1"), HostPort: "5432"}}},
+			Resources: container.Resources{
+				Memory:     512 << 20,
+				MemorySwap: 1 << 30,
+				CPUShares:  512,
+			},
+		},
+		Mounts: []container.MountPoint{
+			{Type: mount.TypeVolume, Name: "pgdata", Destination: "/var/lib/postgresql/data", RW: true},
+			{Type: mount.TypeVolume, Name: "4f2a", Destination: "/scratch", RW: true},
+		},
+		NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"backend": {
+					IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: netip.MustParseAddr("172.20.0.5")},
+					Aliases:    []string{"postgres"},
+					IPAddress:  netip.MustParseAddr("172.20.0.5"),
+				},
+				"monitoring": {Aliases: []string{"db"}},
+			},
+		},
+	}
+}
+
+func TestReconfigureOptionsImageOnly(t *testing.T) {
+	ins := dbInspect()
+	spec := SpecFromInspect(ins, postgresImage())
+	spec.Image = "postgres:16"
+
+	opts, networks, err := reconfigureOptions(ins, postgresImage(), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, host := opts.Config, opts.HostConfig
+	if cfg.Image != "postgres:16" || opts.Name != "db" {
+		t.Errorf("Image, Name = %q, %q, want postgres:16, db", cfg.Image, opts.Name)
+	}
+	// What the old image set is left for the new one.
+	if want := []string{"POSTGRES_PASSWORD=secret"}; !slices.Equal(cfg.Env, want) {
+		t.Errorf("Env = %q, want %q", cfg.Env, want)
+	}
+	if cfg.Entrypoint != nil || cfg.Cmd != nil || cfg.Healthcheck != nil || len(cfg.Labels) != 1 {
+		t.Errorf("config = %+v, want the image's entrypoint, command, healthcheck and labels dropped", cfg)
+	}
+	if cfg.Hostname != "" {
+		t.Errorf("Hostname = %q, want the new container's", cfg.Hostname)
+	}
+	// Everything else is kept.
+	if cfg.User != "999" || !host.Privileged || !slices.Equal(host.CapAdd, []string{"NET_ADMIN"}) ||
+		len(host.DNS) != 1 || host.ShmSize != 256<<20 || host.CPUShares != 512 ||
+		host.Memory != 512<<20 || host.MemorySwap != 1<<30 {
+		t.Errorf("host config = %+v, want it carried over", host)
+	}
+	port := network.MustParsePort("5432/tcp")
+	if b := host.PortBindings[port]; len(b) != 1 || b[0].HostIP.String() != "::1" {
+		t.Errorf("PortBindings = %v, want the loopback binding kept", host.PortBindings)
+	}
+	if want := []string{"pgdata:/var/lib/postgresql/data", "4f2a:/scratch"}; !slices.Equal(host.Binds, want) {
+		t.Errorf("Binds = %q, want %q", host.Binds, want)
+	}
+	ep := opts.NetworkingConfig.EndpointsConfig["backend"]
+	if ep == nil || ep.IPAMConfig == nil || ep.IPAddress.IsValid() || !slices.Equal(ep.Aliases, []string{"postgres"}) {
+		t.Errorf("backend endpoint = %+v, want its static IP and aliases only", ep)
+	}
+	if len(networks) != 1 || networks["monitoring"] == nil {
+		t.Errorf("networks = %v, want monitoring connected after creation", networks)
+	}
+}
+
+func TestReconfigureOptionsEdits(t *testing.T) {
+	ins := dbInspect()
+	spec := SpecFromInspect(ins, postgresImage())
+	spec.Env = append(spec.Env, "TZ=UTC")
+	spec.Ports = []string{"127.0.0.1:6543:5432/tcp"}
+	spec.Memory = "2g"
+	spec.Restart = "always"
+
+	opts, _, err := reconfigureOptions(ins, postgresImage(), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, host := opts.Config, opts.HostConfig
+	if want := []string{"POSTGRES_PASSWORD=secret", "TZ=UTC"}; !slices.Equal(cfg.Env, want) {
+		t.Errorf("Env = %q, want %q", cfg.Env, want)
+	}
+	port := network.MustParsePort("5432/tcp")
+	if b := host.PortBindings[port]; len(b) != 1 || b[0].HostPort != "6543" || b[0].HostIP.String() != "127.0.0.1" {
+		t.Errorf("PortBindings = %v, want 127.0.0.1:6543", host.PortBindings)
+	}
+	// Swap keeps its ratio to memory, or the daemon refuses the limit.
+	if host.Memory != 2<<30 || host.MemorySwap != 4<<30 {
+		t.Errorf("Memory, MemorySwap = %d, %d, want 2g, 4g", host.Memory, host.MemorySwap)
+	}
+	if host.RestartPolicy.Name != container.RestartPolicyAlways {
+		t.Errorf("RestartPolicy = %+v, want always", host.RestartPolicy)
+	}
+	if !host.Privileged || host.CPUShares != 512 {
+		t.Errorf("host config = %+v, want what the form doesn't show carried over", host)
+	}
+	if ins.HostConfig.Memory != 512<<20 || len(ins.HostConfig.Binds) != 1 {
+		t.Error("the inspect result was modified")
+	}
+}
+
+func TestScaleSwap(t *testing.T) {
+	for _, tc := range []struct {
+		mem, swap, newMem, want int64
+	}{
+		{512, 1024, 2048, 4096},
+		{512, -1, 2048, -1},
+		{512, 0, 2048, 0},
+		{0, 0, 2048, 0},
+		{512, 1024, 0, 0},
+	} {
+		if got := scaleSwap(tc.mem, tc.swap, tc.newMem); got != tc.want {
+			t.Errorf("scaleSwap(%d, %d, %d) = %d, want %d", tc.mem, tc.swap, tc.newMem, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moby/moby/api/types/system"
+	"github.com/moby/moby/client"
+)
+
+// SystemInfo gathers what the daemon reports about itself and its disk
+// usage.
+type SystemInfo struct {
+	Info    system.Info
+	Version client.ServerVersionResult
+	Disk    client.DiskUsageResult
+}
+
+// SystemInfo queries info, version and disk usage. Disk usage makes the
+// daemon walk every layer and volume, so it can take a few seconds.
+func (c *Client) SystemInfo(ctx context.Context) (SystemInfo, error) {
+	var s SystemInfo
+	info, err := c.cli.Info(ctx, client.InfoOptions{})
+	if err != nil {
+		return s, err
+	}
+	s.Info = info.Info
+	if s.Version, err = c.cli.ServerVersion(ctx, client.ServerVersionOptions{}); err != nil {
+		return s, err
+	}
+	s.Disk, err = c.cli.DiskUsage(ctx, client.DiskUsageOptions{
+		Containers: true, Images: true, Volumes: true, BuildCache: true,
+		Verbose: true,
+	})
+	return s, err
+}
+
+// PruneEstimate is the space a Prune of kind frees, as far as disk usage
+// tells, false when it can't tell. For images and volumes it is less than
+// the reclaimable space docker system df shows: that counts every unused
+// one, Prune only removes dangling images and anonymous volumes.
+func (s SystemInfo) PruneEstimate(kind PruneKind) (int64, bool) {
+	switch kind {
+	case PruneContainers:
+		return s.Disk.Containers.Reclaimable, true
+	case PruneImages:
+		var size int64
+		for _, img := range s.Disk.Images.Items {
+			if len(realTags(img.RepoTags)) == 0 && img.Containers == 0 {
+				size += img.Size - max(img.SharedSize, 0)
+			}
+		}
+		return size, true
+	case PruneVolumes:
+		var size int64
+		for _, v := range s.Disk.Volumes.Items {
+			_, anonymous := v.Labels[anonymousVolumeLabel]
+			if u := v.UsageData; anonymous && u != nil && u.RefCount == 0 && u.Size > 0 {
+				size += u.Size
+			}
+		}
+		return size, true
+	}
+	return 0, false
+}
+
+// PruneKind is a category of unused objects.
+type PruneKind string
+
+const (
+	PruneContainers PruneKind = "containers"
+	PruneImages     PruneKind = "images"
+	PruneVolumes    PruneKind = "volumes"
+	PruneNetworks   PruneKind = "networks"
+	PruneBuildCache PruneKind = "build-cache"
+)
+
+// PruneKinds lists every category, in the order docker system df shows
+// them.
+var PruneKinds = []PruneKind{PruneImages, PruneContainers, PruneVolumes, PruneBuildCache, PruneNetworks}
+
+// PruneResult is what a prune removed.
+type PruneResult struct {
+	Kind      PruneKind
+	Deleted   []string
+	Reclaimed uint64
+}
+
+// Prune removes the unused objects of a kind with the same defaults as
+// docker system prune: stopped containers, dangling images, anonymous
+// volumes nothing uses, unused networks and dangling build cache.
+func (c *Client) Prune(ctx context.Context, kind PruneKind) (PruneResult, error) {
+	res := PruneResult{Kind: kind}
+	err := c.audited(ctx, "", "prune", map[string]any{"kind": string(kind)}, func() error {
+		switch kind {
+		case PruneContainers:
+			r, err := c.cli.ContainerPrune(ctx, client.ContainerPruneOptions{})
+			res.Deleted, res.Reclaimed = r.Report.ContainersDeleted, r.Report.SpaceReclaimed
+			return err
+		case PruneImages:
+			r, err := c.cli.ImagePrune(ctx, client.ImagePruneOptions{})
+			for _, d := range r.Report.ImagesDeleted {
+				if d.Deleted != "" {
+					res.Deleted = append(res.Deleted, d.Deleted)
+				}
+			}
+			res.Reclaimed = r.Report.SpaceReclaimed
+			return err
+		case PruneVolumes:
+			r, err := c.cli.VolumePrune(ctx, client.VolumePruneOptions{})
+			res.Deleted, res.Reclaimed = r.Report.VolumesDeleted, r.Report.SpaceReclaimed
+			return err
+		case PruneNetworks:
+			r, err := c.cli.NetworkPrune(ctx, client.NetworkPruneOptions{})
+			res.Deleted = r.Report.NetworksDeleted
+			return err
+		case PruneBuildCache:
+			r, err := c.cli.BuildCachePrune(ctx, client.BuildCachePruneOptions{})
+			res.Deleted, res.Reclaimed = r.Report.CachesDeleted, r.Report.SpaceReclaimed
+			return err
+		}
+		return fmt.Errorf("unknown prune kind %q", kind)
+	})
+	return res, err
+}
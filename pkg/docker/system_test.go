@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/api/types/volume"
+	"github.com/moby/moby/client"
+)
+
+func TestPruneEstimate(t *testing.T) {
+	s := SystemInfo{Disk: client.DiskUsageResult{
+		Images: client.ImagesDiskUsage{
+			Reclaimable: 700,
+			Items: []image.Summary{
+				{RepoTags: []string{"app:1"}, Size: 400},         // unused but tagged
+				{RepoTags: []string{"<none>:<none>"}, Size: 100}, // dangling
+				{Size: 250, SharedSize: 50},                      // dangling, partly shared
+				{Size: 300, Containers: 1},                       // in use
+			},
+		},
+		Volumes: client.VolumesDiskUsage{
+			Reclaimable: 60,
+			Items: []volume.Volume{
+				{Name: "data", UsageData: &volume.UsageData{Size: 40}},
+				{Name: "4f2a", Labels: map[string]string{anonymousVolumeLabel: ""}, UsageData: &volume.UsageData{Size: 20}},
+				{Name: "9c1e", Labels: map[string]string{anonymousVolumeLabel: ""}, UsageData: &volume.UsageData{Size: 5, RefCount: 1}},
+			},
+		},
+	}}
+	for _, tc := range []struct {
+		kind PruneKind
+		want int64
+		ok   bool
+	}{
+		{PruneImages, 300, true},
+		{PruneVolumes, 20, true},
+		{PruneBuildCache, 0, false},
+	} {
+		if got, ok := s.PruneEstimate(tc.kind); got != tc.want || ok != tc.ok {
+			t.Errorf("PruneEstimate(%v) = %d, %v, want %d, %v", tc.kind, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+// UpdateSpec holds the settings that can be changed on a live container.
+// Empty fields are left untouched, as with docker update. Limits take
+// "unlimited" or -1 to lift them.
+//
+// The daemon can't lift a memory or CPU limit from a live container, it
+// only raises it; Update raises them to the host's memory and CPU count,
+// which is the same. When memory is changed and swap isn't, swap is scaled
+// along, keeping its ratio to memory: the daemon refuses a memory limit
+// above the swap limit.
+type UpdateSpec struct {
+	Memory     string // --memory 512m
+	MemorySwap string // --memory-swap 1g
+	CPUs       string // --cpus 1.5
+	CPUShares  string // --cpu-shares 1024
+	PidsLimit  string // --pids-limit 200
+	Restart    string // --restart
+}
+
+// Unlimited is the value lifting a limit in an UpdateSpec.
+const Unlimited = "unlimited"
+
+func isUnlimited(v string) bool {
+	return v == "-1" || strings.EqualFold(v, Unlimited)
+}
+
+// hostCapacity is the memory and CPUs of the daemon's host, what a lifted
+// memory or CPU limit is raised to.
+type hostCapacity struct {
+	memory int64
+	cpus   int
+}
+
+// UpdateSpecFromHostConfig prefills an UpdateSpec with the current values.
+func UpdateSpecFromHostConfig(hc *container.HostConfig) UpdateSpec {
+	var spec UpdateSpec
+	if hc == nil {
+		return spec
+	}
+	if hc.Memory > 0 {
+		spec.Memory = FormatMemory(hc.Memory)
+	}
+	if hc.MemorySwap > 0 {
+		spec.MemorySwap = FormatMemory(hc.MemorySwap)
+	} else if hc.MemorySwap < 0 {
+		spec.MemorySwap = Unlimited
+	}
+	if hc.NanoCPUs > 0 {
+		spec.CPUs = strconv.FormatFloat(float64(hc.NanoCPUs)/1e9, 'f', -1, 64)
+	}
+	if hc.CPUShares > 0 {
+		spec.CPUShares = strconv.FormatInt(hc.CPUShares, 10)
+	}
+	if hc.PidsLimit != nil && *hc.PidsLimit > 0 {
+		spec.PidsLimit = strconv.FormatInt(*hc.PidsLimit, 10)
+	}
+	if !hc.RestartPolicy.IsNone() {
+		spec.Restart = string(hc.RestartPolicy.Name)
+		if hc.RestartPolicy.IsOnFailure() && hc.RestartPolicy.MaximumRetryCount > 0 {
+			spec.Restart += ":" + strconv.Itoa(hc.RestartPolicy.MaximumRetryCount)
+		}
+	}
+	return spec
+}
+
+func (s UpdateSpec) Validate() error {
+	_, err := s.options(hostCapacity{}, container.Resources{})
+	return err
+}
+
+// options builds the update from the host's capacity and the container's
+// current limits, cur.
+func (s UpdateSpec) options(host hostCapacity, cur container.Resources) (client.ContainerUpdateOptions, error) {
+	res := &container.Resources{}
+	opts := client.ContainerUpdateOptions{Resources: res}
+
+	switch {
+	case isUnlimited(s.Memory):
+		res.Memory = host.memory
+		// Swap can't stay below the raised limit.
+		if s.MemorySwap == "" {
+			res.MemorySwap = -1
+		}
+	case s.Memory != "":
+		mem, err := units.RAMInBytes(s.Memory)
+		if err != nil || mem <= 0 {
+			return opts, fmt.Errorf("invalid memory %q: expected a size or %s", s.Memory, Unlimited)
+		}
+		res.Memory = mem
+		if s.MemorySwap == "" {
+			res.MemorySwap = scaleSwap(cur.Memory, cur.MemorySwap, mem)
+		}
+	}
+
+	if s.MemorySwap != "" {
+		if isUnlimited(s.MemorySwap) {
+			res.MemorySwap = -1
+		} else {
+			swap, err := units.RAMInBytes(s.MemorySwap)
+			if err != nil {
+				return opts, fmt.Errorf("invalid memory swap %q: %w", s.MemorySwap, err)
+			}
+			if res.Memory > 0 && swap < res.Memory {
+				return opts, fmt.Errorf("memory swap must be at least the memory limit")
+			}
+			res.MemorySwap = swap
+		}
+	}
+
+	switch {
+	case isUnlimited(s.CPUs):
+		res.NanoCPUs = int64(host.cpus) * 1e9
+	case s.CPUs != "":
+		cpus, err := strconv.ParseFloat(s.CPUs, 64)
+		if err != nil || cpus <= 0 {
+			return opts, fmt.Errorf("invalid cpus %q: expected a positive number or %s", s.CPUs, Unlimited)
+		}
+		res.NanoCPUs = int64(cpus * 1e9)
+	}
+
+	if s.CPUShares != "" {
+		shares, err := strconv.ParseInt(s.CPUShares, 10, 64)
+		if err != nil || shares < 2 {
+			return opts, fmt.Errorf("invalid cpu shares %q: expected an integer >= 2", s.CPUShares)
+		}
+		res.CPUShares = shares
+	}
+
+	switch {
+	case isUnlimited(s.PidsLimit):
+		limit := int64(-1)
+		res.PidsLimit = &limit
+	case s.PidsLimit != "":
+		limit, err := strconv.ParseInt(s.PidsLimit, 10, 64)
+		if err != nil || limit <= 0 {
+			return opts, fmt.Errorf("invalid pids limit %q: expected a positive number or %s", s.PidsLimit, Unlimited)
+		}
+		res.PidsLimit = &limit
+	}
+
+	if s.Restart != "" {
+		policy, err := ParseRestartPolicy(s.Restart)
+		if err != nil {
+			return opts, err
+		}
+		opts.RestartPolicy = &policy
+	}
+
+	return opts, nil
+}
+
+// Update applies spec to a running container without recreating it and
+// returns the daemon's warnings, if any.
+func (c *Client) Update(ctx context.Context, id string, spec UpdateSpec) ([]string, error) {
+	var host hostCapacity
+	if isUnlimited(spec.Memory) || isUnlimited(spec.CPUs) {
+		info, err := c.cli.Info(ctx, client.InfoOptions{})
+		if err != nil {
+			return nil, err
+		}
+		host = hostCapacity{memory: info.Info.MemTotal, cpus: info.Info.NCPU}
+	}
+	var cur container.Resources
+	if spec.Memory != "" && spec.MemorySwap == "" {
+		ins, err := c.Inspect(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if ins.HostConfig != nil {
+			cur = ins.HostConfig.Resources
+		}
+	}
+	opts, err := spec.options(host, cur)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	params := map[string]any{
+		"memory": spec.Memory, "memory_swap": spec.MemorySwap, "cpus": spec.CPUs,
+		"cpu_shares": spec.CPUShares, "pids_limit": spec.PidsLimit, "restart": spec.Restart,
+	}
+	err = c.audited(ctx, id, "update", params, func() error {
+		result, err := c.cli.ContainerUpdate(ctx, id, opts)
+		warnings = result.Warnings
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestUpdateSpecUnlimited(t *testing.T) {
+	host := hostCapacity{memory: 16 << 30, cpus: 8}
+
+	opts, err := UpdateSpec{Memory: "unlimited", CPUs: "-1", PidsLimit: "Unlimited"}.options(host, container.Resources{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := opts.Resources
+	if res.Memory != host.memory || res.MemorySwap != -1 {
+		t.Errorf("memory, swap = %d, %d, want the host's %d and -1", res.Memory, res.MemorySwap, host.memory)
+	}
+	if res.NanoCPUs != 8e9 {
+		t.Errorf("NanoCPUs = %d, want every CPU of the host", res.NanoCPUs)
+	}
+	if res.PidsLimit == nil || *res.PidsLimit != -1 {
+		t.Errorf("PidsLimit = %v, want -1", res.PidsLimit)
+	}
+
+	// An edited swap is kept when memory is lifted.
+	opts, err = UpdateSpec{Memory: "unlimited", MemorySwap: "unlimited"}.options(host, container.Resources{})
+	if err != nil || opts.Resources.MemorySwap != -1 {
+		t.Errorf("swap = %d, %v, want -1", opts.Resources.MemorySwap, err)
+	}
+
+	// Nothing is changed without being asked to.
+	opts, err = UpdateSpec{Memory: "1g"}.options(host, container.Resources{})
+	if err != nil || opts.Resources.MemorySwap != 0 || opts.Resources.NanoCPUs != 0 || opts.Resources.PidsLimit != nil {
+		t.Errorf("resources = %+v, %v, want only memory set", opts.Resources, err)
+	}
+
+	// 0 used to be silently ignored.
+	for _, spec := range []UpdateSpec{{Memory: "0"}, {CPUs: "0"}, {PidsLimit: "0"}} {
+		if err := spec.Validate(); err == nil {
+			t.Errorf("%+v: no error", spec)
+		}
+	}
+}
+
+func TestUpdateSpecRaiseMemoryAboveSwap(t *testing.T) {
+	// 512m with the default swap, twice that.
+	cur := container.Resources{Memory: 512 << 20, MemorySwap: 1 << 30}
+
+	opts, err := UpdateSpec{Memory: "2g"}.options(hostCapacity{}, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := opts.Resources; res.Memory != 2<<30 || res.MemorySwap != 4<<30 {
+		t.Errorf("memory, swap = %d, %d, want 2g and swap raised along to 4g", res.Memory, res.MemorySwap)
+	}
+
+	// An edited swap is sent as is.
+	opts, err = UpdateSpec{Memory: "2g", MemorySwap: "3g"}.options(hostCapacity{}, cur)
+	if err != nil || opts.Resources.MemorySwap != 3<<30 {
+		t.Errorf("swap = %d, %v, want 3g", opts.Resources.MemorySwap, err)
+	}
+
+	// Unlimited swap stays so.
+	cur.MemorySwap = -1
+	opts, err = UpdateSpec{Memory: "2g"}.options(hostCapacity{}, cur)
+	if err != nil || opts.Resources.MemorySwap != -1 {
+		t.Errorf("swap = %d, %v, want -1", opts.Resources.MemorySwap, err)
+	}
+}
@@ -0,0 +1,162 @@
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/moby/client"
+)
+
+// UploadTarget resolves where hostPath lands when copied to dest, with the
+// same rules as docker cp: into dest when it is an existing directory,
+// otherwise as dest itself. It returns the directory to extract into, the
+// name the entry gets there, and whether that final path already exists.
+func (c *Client) UploadTarget(ctx context.Context, id, hostPath, dest string) (dir, name string, exists bool, err error) {
+	stat, err := c.StatPath(ctx, id, dest)
+	switch {
+	case err == nil && stat.Mode.IsDir():
+		dir, name = dest, filepath.Base(hostPath)
+	case err == nil || cerrdefs.IsNotFound(err):
+		dir, name = path.Dir(dest), path.Base(dest)
+	default:
+		return "", "", false, err
+	}
+
+	_, err = c.StatPath(ctx, id, path.Join(dir, name))
+	switch {
+	case err == nil:
+		return dir, name, true, nil
+	case cerrdefs.IsNotFound(err):
+		return dir, name, false, nil
+	default:
+		return "", "", false, err
+	}
+}
+
+// Upload copies hostPath, file or directory, into the container as
+// dir/name. progress, if set, is called as bytes of file content are sent.
+func (c *Client) Upload(ctx context.Context, id, hostPath, dir, name string, progress func(sent, total int64)) error {
+	params := map[string]any{"source": hostPath, "destination": path.Join(dir, name)}
+	return c.audited(ctx, id, "upload", params, func() error {
+		return c.upload(ctx, id, hostPath, dir, name, progress)
+	})
+}
+
+func (c *Client) upload(ctx context.Context, id, hostPath, dir, name string, progress func(sent, total int64)) error {
+	total, err := treeSize(hostPath)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTar(pw, hostPath, name, func(n int64) {
+			if progress != nil {
+				progress(n, total)
+			}
+		}))
+	}()
+
+	_, err = c.cli.CopyToContainer(ctx, id, client.CopyToContainerOptions{
+		DestinationPath: dir,
+		Content:         pr,
+	})
+	pr.CloseWithError(err)
+	return err
+}
+
+func treeSize(root string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// writeTar archives root under the name base, reporting the running total
+// of file bytes written.
+func writeTar(w io.Writer, root, base string, sent func(int64)) error {
+	tw := tar.NewWriter(w)
+	var written int64
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		name := path.Join(base, filepath.ToSlash(rel))
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		buf := make([]byte, 32*1024)
+		for {
+			n, rerr := f.Read(buf)
+			if n > 0 {
+				if _, err := tw.Write(buf[:n]); err != nil {
+					return err
+				}
+				written += int64(n)
+				sent(written)
+			}
+			if rerr == io.EOF {
+				return nil
+			}
+			if rerr != nil {
+				return rerr
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
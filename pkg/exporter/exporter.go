@@ -0,0 +1,185 @@
+// Package exporter serves container metrics in the Prometheus text format.
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/stats"
+	"github.com/moby/moby/api/types/container"
+)
+
+const (
+	// concurrency bounds the inspect/stats calls made per scrape.
+	concurrency = 8
+	// scrapeTimeout caps a whole scrape. Stats readings are one-shot and
+	// return right away, CPU usage comes from the previous scrape's through
+	// the Sampler; the cap is for a daemon that is slow to answer.
+	scrapeTimeout = 15 * time.Second
+
+	composeProjectLabel = "com.docker.compose.project"
+)
+
+// Exporter collects metrics from the Docker daemon on every scrape.
+type Exporter struct {
+	client  *docker.Client
+	sampler *stats.Sampler
+}
+
+func New(client *docker.Client) *Exporter {
+	return &Exporter{client: client, sampler: stats.NewSampler()}
+}
+
+type family struct {
+	name string
+	kind string // gauge or counter
+	help string
+}
+
+var (
+	stateFamily      = family{"stackr_container_state", "gauge", "Container state, 1 for the current state."}
+	healthFamily     = family{"stackr_container_health", "gauge", "Container health status, 1 for the current status."}
+	restartsFamily   = family{"stackr_container_restart_count", "gauge", "Number of times the daemon restarted the container."}
+	cpuFamily        = family{"stackr_container_cpu_percent", "gauge", "CPU usage in percent of one core."}
+	memUsageFamily   = family{"stackr_container_memory_usage_bytes", "gauge", "Memory used by the container."}
+	memLimitFamily   = family{"stackr_container_memory_limit_bytes", "gauge", "Memory limit of the container."}
+	netRxFamily      = family{"stackr_container_network_receive_bytes_total", "counter", "Bytes received over all interfaces."}
+	netTxFamily      = family{"stackr_container_network_transmit_bytes_total", "counter", "Bytes sent over all interfaces."}
+	blockReadFamily  = family{"stackr_container_block_read_bytes_total", "counter", "Bytes read from block devices."}
+	blockWriteFamily = family{"stackr_container_block_write_bytes_total", "counter", "Bytes written to block devices."}
+
+	families = []family{
+		stateFamily, healthFamily, restartsFamily, cpuFamily, memUsageFamily,
+		memLimitFamily, netRxFamily, netTxFamily, blockReadFamily, blockWriteFamily,
+	}
+)
+
+// metrics accumulates series per family while containers are collected
+// concurrently.
+type metrics struct {
+	mu     sync.Mutex
+	series map[string][]string
+}
+
+func (m *metrics) add(f family, labels []string, value float64) {
+	var b strings.Builder
+	b.WriteString(f.name)
+	b.WriteString("{")
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+	}
+	fmt.Fprintf(&b, "} %g", value)
+
+	m.mu.Lock()
+	m.series[f.name] = append(m.series[f.name], b.String())
+	m.mu.Unlock()
+}
+
+func (m *metrics) write(w io.Writer) {
+	for _, f := range families {
+		lines := m.series[f.name]
+		if len(lines) == 0 {
+			continue
+		}
+		sort.Strings(lines)
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, l := range lines {
+			fmt.Fprintln(w, l)
+		}
+	}
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), scrapeTimeout)
+	defer cancel()
+
+	m, err := e.collect(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.write(w)
+}
+
+// collect gathers the current metrics of every container.
+func (e *Exporter) collect(ctx context.Context) (*metrics, error) {
+	containers, err := e.client.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &metrics{series: map[string][]string{}}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, c := range containers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			e.collectContainer(ctx, c, m)
+		}()
+	}
+	wg.Wait()
+	return m, nil
+}
+
+func (e *Exporter) collectContainer(ctx context.Context, c container.Summary, m *metrics) {
+	name := c.ID[:12]
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+	labels := []string{"name", name, "image", c.Image, "project", c.Labels[composeProjectLabel]}
+
+	m.add(stateFamily, append(labels, "state", string(c.State)), 1)
+
+	health := string(container.NoHealthcheck)
+	if c.Health != nil && c.Health.Status != "" {
+		health = string(c.Health.Status)
+	}
+	m.add(healthFamily, append(labels, "status", health), 1)
+
+	// Restart count is only exposed by inspect.
+	if ins, err := e.client.Inspect(ctx, c.ID); err == nil {
+		m.add(restartsFamily, labels, float64(ins.RestartCount))
+	}
+
+	if c.State != container.StateRunning {
+		return
+	}
+	s, err := e.client.Stats(ctx, c.ID)
+	if err != nil {
+		return
+	}
+	rx, tx := stats.NetworkIO(s)
+	read, write := stats.BlockIO(s)
+	// CPU usage is over the time since the previous scrape, there is none
+	// on the first.
+	e.sampler.Prime(c.ID, s)
+	if stats.HasPrevious(s) {
+		m.add(cpuFamily, labels, stats.CPUPercent(s))
+	}
+	m.add(memUsageFamily, labels, float64(stats.MemoryUsage(s)))
+	m.add(memLimitFamily, labels, float64(s.MemoryStats.Limit))
+	m.add(netRxFamily, labels, float64(rx))
+	m.add(netTxFamily, labels, float64(tx))
+	m.add(blockReadFamily, labels, float64(read))
+	m.add(blockWriteFamily, labels, float64(write))
+}
+
+func escapeLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
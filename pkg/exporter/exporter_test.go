@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricsWrite(t *testing.T) {
+	m := &metrics{series: map[string][]string{}}
+	web := []string{"name", "web", "image", "nginx:1.27", "project", "shop"}
+	db := []string{"name", "db", "image", "postgres:16", "project", ""}
+	m.add(memUsageFamily, web, 52428800)
+	m.add(stateFamily, append(db, "state", "exited"), 1)
+	m.add(stateFamily, append(web, "state", "running"), 1)
+	m.add(cpuFamily, web, 12.5)
+	m.add(restartsFamily, []string{"name", `say "hi"\now`, "image", "a\nb", "project", ""}, 3)
+
+	var b strings.Builder
+	m.write(&b)
+	want := `# HELP stackr_container_state Container state, 1 for the current state.
+# TYPE stackr_container_state gauge
+stackr_container_state{name="db",image="postgres:16",project="",state="exited"} 1
+stackr_container_state{name="web",image="nginx:1.27",project="shop",state="running"} 1
+# HELP stackr_container_restart_count Number of times the daemon restarted the container.
+# TYPE stackr_container_restart_count gauge
+stackr_container_restart_count{name="say \"hi\"\\now",image="a\nb",project=""} 3
+# HELP stackr_container_cpu_percent CPU usage in percent of one core.
+# TYPE stackr_container_cpu_percent gauge
+stackr_container_cpu_percent{name="web",image="nginx:1.27",project="shop"} 12.5
+# HELP stackr_container_memory_usage_bytes Memory used by the container.
+# TYPE stackr_container_memory_usage_bytes gauge
+stackr_container_memory_usage_bytes{name="web",image="nginx:1.27",project="shop"} 5.24288e+07
+`
+	if got := b.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+// Package format holds the human readable renderings shared by the TUI and
+// the CLI output, and the splitting of command lines typed into either.
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+func Bytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func ShortStatus(status string) string {
+	// Simplify "Up 3 days" -> "Up 3d", "Exited (0) 2 hours ago" -> "Exited"
+	if strings.HasPrefix(status, "Up") {
+		parts := strings.Fields(status)
+		if len(parts) >= 3 {
+			return fmt.Sprintf("Up %s%c", parts[1], parts[2][0])
+		}
+		return status
+	}
+	if strings.HasPrefix(status, "Exited") {
+		return "Exited"
+	}
+	if strings.HasPrefix(status, "Created") {
+		return "Created"
+	}
+	return Truncate(status, 12)
+}
+
+func Ports(ports []container.PortSummary) string {
+	if len(ports) == 0 {
+		return ""
+	}
+	var parts []string
+	seen := make(map[string]bool)
+	for _, p := range ports {
+		var key string
+		if p.PublicPort != 0 {
+			key = fmt.Sprintf("%d:%d", p.PublicPort, p.PrivatePort)
+		} else {
+			key = fmt.Sprintf("%d", p.PrivatePort)
+		}
+		if !seen[key] {
+			parts = append(parts, key)
+			seen[key] = true
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func Truncate(s string, max int) string {
+	if max < 4 {
+		max = 4
+	}
+	if len(s) > max {
+		return s[:max-3] + "..."
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package history
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/stats"
+	"github.com/moby/moby/api/types/container"
+)
+
+const (
+	// concurrency bounds the stats calls made per sampling round.
+	concurrency = 8
+	// pruneEvery is how often old day files are cleaned up.
+	pruneEvery = time.Hour
+)
+
+// Recorder samples every running container into a Store.
+type Recorder struct {
+	client  *docker.Client
+	store   *Store
+	sampler *stats.Sampler
+}
+
+func NewRecorder(client *docker.Client, store *Store) *Recorder {
+	return &Recorder{client: client, store: store, sampler: stats.NewSampler()}
+}
+
+// Run records a sample of each running container every Interval until ctx
+// is cancelled. Failed samples are skipped, the next round tries again.
+// Rounds are skipped while another stackr process records into the same
+// store.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		if now := time.Now(); r.store.lease(now) {
+			if now.Sub(lastPrune) >= pruneEvery {
+				r.store.Prune(now)
+				lastPrune = now
+			}
+			r.sample(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Recorder) sample(ctx context.Context) {
+	containers, err := r.client.ListContainers(ctx)
+	if err != nil {
+		return
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, c := range containers {
+		if c.State != container.StateRunning || len(c.Names) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			s, err := r.client.Stats(ctx, c.ID)
+			if err != nil {
+				return
+			}
+			// CPU usage is over the minute since the last sample, the
+			// first sample of a container only primes the sampler.
+			r.sampler.Prime(c.ID, s)
+			if !stats.HasPrevious(s) {
+				return
+			}
+			rx, tx := stats.NetworkIO(s)
+			read, write := stats.BlockIO(s)
+			r.store.Append(strings.TrimPrefix(c.Names[0], "/"), Sample{
+				Time:       time.Now(),
+				CPU:        stats.CPUPercent(s),
+				Mem:        stats.MemoryUsage(s),
+				MemLimit:   s.MemoryStats.Limit,
+				NetRx:      rx,
+				NetTx:      tx,
+				BlockRead:  read,
+				BlockWrite: write,
+			})
+		}()
+	}
+	wg.Wait()
+}
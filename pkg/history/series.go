@@ -0,0 +1,83 @@
+package history
+
+import "time"
+
+// Point aggregates the samples falling into one chart column. Rates are in
+// bytes per second. Valid is false for columns without samples.
+type Point struct {
+	Valid        bool
+	CPU          float64
+	Mem          float64
+	NetRx, NetTx float64
+	BlockRead    float64
+	BlockWrite   float64
+}
+
+// Bucket spreads samples over n equal columns between from and to. CPU and
+// memory are averaged; I/O counters are turned into rates from consecutive
+// samples, ignoring drops caused by restarts.
+func Bucket(samples []Sample, from, to time.Time, n int) []Point {
+	points := make([]Point, n)
+	if n == 0 || !to.After(from) {
+		return points
+	}
+	width := to.Sub(from) / time.Duration(n)
+	if width <= 0 {
+		width = 1
+	}
+
+	counts := make([]int, n)
+	elapsed := make([]float64, n)
+	for i, s := range samples {
+		if s.Time.Before(from) || !s.Time.Before(to) {
+			continue
+		}
+		b := int(s.Time.Sub(from) / width)
+		if b >= n {
+			b = n - 1
+		}
+		p := &points[b]
+		p.Valid = true
+		p.CPU += s.CPU
+		p.Mem += float64(s.Mem)
+		counts[b]++
+
+		if i == 0 {
+			continue
+		}
+		prev := samples[i-1]
+		dt := s.Time.Sub(prev.Time).Seconds()
+		// A gap much longer than the sampling interval means nothing was
+		// recording, spreading the delta over it would invent a rate.
+		if dt <= 0 || dt > 3*Interval.Seconds() {
+			continue
+		}
+		elapsed[b] += dt
+		p.NetRx += delta(prev.NetRx, s.NetRx)
+		p.NetTx += delta(prev.NetTx, s.NetTx)
+		p.BlockRead += delta(prev.BlockRead, s.BlockRead)
+		p.BlockWrite += delta(prev.BlockWrite, s.BlockWrite)
+	}
+
+	for i := range points {
+		p := &points[i]
+		if counts[i] > 0 {
+			p.CPU /= float64(counts[i])
+			p.Mem /= float64(counts[i])
+		}
+		if elapsed[i] > 0 {
+			p.NetRx /= elapsed[i]
+			p.NetTx /= elapsed[i]
+			p.BlockRead /= elapsed[i]
+			p.BlockWrite /= elapsed[i]
+		}
+	}
+	return points
+}
+
+func delta(prev, cur uint64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur - prev)
+}
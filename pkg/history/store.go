@@ -0,0 +1,197 @@
+// Package history records container stats samples on disk so resource
+// usage can be charted over days.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Interval is the time between two recorded samples.
+	Interval = time.Minute
+
+	// defaultRetentionDays keeps a little more than the longest chart range.
+	defaultRetentionDays = 8
+
+	dayLayout = "2006-01-02"
+
+	// leaseFile holds the PID of the process recording into the store.
+	// Container names can't start with a dot, it can't clash with one.
+	leaseFile = ".recorder"
+)
+
+// Config is the history section of the config file.
+type Config struct {
+	Disabled      bool `json:"disabled,omitempty"`
+	RetentionDays int  `json:"retention_days,omitempty"`
+}
+
+func (c Config) Validate() error {
+	if c.RetentionDays < 0 {
+		return fmt.Errorf("history retention_days must not be negative")
+	}
+	return nil
+}
+
+// Sample is one stats reading of a container. Network and block I/O are the
+// counters as reported by the daemon, they reset when the container
+// restarts.
+type Sample struct {
+	Time       time.Time `json:"t"`
+	CPU        float64   `json:"cpu"`
+	Mem        uint64    `json:"mem"`
+	MemLimit   uint64    `json:"mem_limit"`
+	NetRx      uint64    `json:"rx"`
+	NetTx      uint64    `json:"tx"`
+	BlockRead  uint64    `json:"blk_read"`
+	BlockWrite uint64    `json:"blk_write"`
+}
+
+// Store keeps samples as one append-only JSONL file per container and day:
+// <dir>/<container name>/<yyyy-mm-dd>.jsonl. History follows the container
+// name so it survives the container being recreated.
+type Store struct {
+	dir       string
+	retention int
+}
+
+func Open(dir string, cfg Config) *Store {
+	retention := cfg.RetentionDays
+	if retention == 0 {
+		retention = defaultRetentionDays
+	}
+	return &Store{dir: dir, retention: retention}
+}
+
+func (s *Store) Append(name string, sample Sample) error {
+	dir := filepath.Join(s.dir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	day := sample.Time.UTC().Format(dayLayout)
+	f, err := os.OpenFile(filepath.Join(dir, day+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	// A single write keeps lines whole when several stackr processes record
+	// at once.
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Load returns the samples of the named container taken at or after since,
+// oldest first. Unreadable lines, such as one cut short by a crash, are
+// skipped.
+func (s *Store) Load(name string, since time.Time) ([]Sample, error) {
+	files, err := os.ReadDir(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	first := since.UTC().Format(dayLayout)
+	var samples []Sample
+	for _, f := range files {
+		day := strings.TrimSuffix(f.Name(), ".jsonl")
+		if day < first {
+			continue
+		}
+		if err := readDay(filepath.Join(s.dir, name, f.Name()), since, &samples); err != nil {
+			return nil, err
+		}
+	}
+	return samples, nil
+}
+
+func readDay(p string, since time.Time, samples *[]Sample) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var sample Sample
+		if json.Unmarshal(sc.Bytes(), &sample) != nil || sample.Time.Before(since) {
+			continue
+		}
+		*samples = append(*samples, sample)
+	}
+	return sc.Err()
+}
+
+// Prune deletes day files past the retention, and directories of
+// containers left without history.
+func (s *Store) Prune(now time.Time) error {
+	containers, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	oldest := now.UTC().AddDate(0, 0, -s.retention).Format(dayLayout)
+	for _, c := range containers {
+		if !c.IsDir() {
+			continue
+		}
+		dir := filepath.Join(s.dir, c.Name())
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		kept := 0
+		for _, f := range files {
+			if strings.TrimSuffix(f.Name(), ".jsonl") >= oldest {
+				kept++
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+				return err
+			}
+		}
+		if kept == 0 {
+			os.Remove(dir)
+		}
+	}
+	return nil
+}
+
+// lease reports whether this process is the one recording into the store,
+// taking the lease over when its holder hasn't renewed it for two
+// intervals. The TUI and stackr watch both record, running them side by
+// side would otherwise write every sample twice.
+func (s *Store) lease(now time.Time) bool {
+	path := filepath.Join(s.dir, leaseFile)
+	pid := strconv.Itoa(os.Getpid())
+	if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) != pid {
+		if info, err := os.Stat(path); err == nil && now.Sub(info.ModTime()) < 2*Interval {
+			return false
+		}
+	}
+	// Failing to write the lease is no reason not to record.
+	if err := os.MkdirAll(s.dir, 0o755); err == nil {
+		os.WriteFile(path, []byte(pid+"\n"), 0o644)
+	}
+	return true
+}
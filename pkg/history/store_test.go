@@ -0,0 +1,44 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLease(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "history"), Config{})
+	now := time.Now()
+
+	if !s.lease(now) {
+		t.Fatal("no lease on a new store")
+	}
+	if !s.lease(now.Add(Interval)) {
+		t.Error("lease not renewed by its holder")
+	}
+
+	// Another process recording, stackr watch next to the TUI.
+	path := filepath.Join(s.dir, leaseFile)
+	if err := os.WriteFile(path, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if s.lease(now) {
+		t.Error("lease taken from a live holder")
+	}
+
+	// Its holder stopped renewing it.
+	stale := now.Add(-3 * Interval)
+	if err := os.Chtimes(path, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	if !s.lease(now) {
+		t.Error("stale lease not taken over")
+	}
+	data, _ := os.ReadFile(path)
+	if got := strings.TrimSpace(string(data)); got != strconv.Itoa(os.Getpid()) {
+		t.Errorf("lease holder = %s, want this process", got)
+	}
+}
@@ -0,0 +1,122 @@
+// Package notes keeps stackr-side annotations on containers: a free text
+// note and tags the team can search for, stored locally since they are
+// nobody else's business than the people running stackr.
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Note is the annotation of one container.
+type Note struct {
+	Text    string    `json:"text,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+func (n Note) Empty() bool {
+	return strings.TrimSpace(n.Text) == "" && len(n.Tags) == 0
+}
+
+// HasTag reports whether n carries tag, ignoring case.
+func (n Note) HasTag(tag string) bool {
+	for _, t := range n.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store keeps the notes of every container in one JSON file, keyed by
+// container ID. A nil *Store has no notes and drops writes.
+type Store struct {
+	path string
+
+	mu    sync.Mutex
+	notes map[string]Note
+}
+
+// Open loads the notes at path. A missing file is an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, notes: map[string]Note{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.notes); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the note of the container id, the zero Note if it has none.
+func (s *Store) Get(id string) Note {
+	if s == nil {
+		return Note{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notes[id]
+}
+
+// Set replaces the note of the container id and saves the store. An empty
+// note removes it.
+func (s *Store) Set(id string, n Note) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n.Empty() {
+		delete(s.notes, id)
+	} else {
+		n.Updated = time.Now()
+		s.notes[id] = n
+	}
+	return s.save()
+}
+
+// Move carries the note of a container over to the one that replaced it,
+// as after a recreate.
+func (s *Store) Move(oldID, newID string) error {
+	if s == nil || oldID == newID {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.notes[oldID]
+	if !ok {
+		return nil
+	}
+	delete(s.notes, oldID)
+	s.notes[newID] = n
+	return s.save()
+}
+
+// save writes the store to a temporary file renamed over the old one, so a
+// crash never leaves half a file behind. Callers hold mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
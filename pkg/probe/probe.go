@@ -0,0 +1,71 @@
+// Package probe checks whether a published port answers, over TCP and, for
+// ports expected to serve a web page, HTTP.
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Timeout bounds each step of a probe.
+const Timeout = 3 * time.Second
+
+// Result is the outcome of a probe. Connect is the time the TCP handshake
+// took; Status and Response are only set when an HTTP request got an
+// answer, HTTPErr when it was tried and failed.
+type Result struct {
+	Connect  time.Duration
+	Err      error
+	Status   int
+	Response time.Duration
+	HTTPErr  error
+}
+
+// Check connects to address and, if url is set, requests it. Redirects are
+// reported rather than followed and certificates are not verified: the
+// question is whether the container answers, not whether it is set up for
+// the outside world.
+func Check(ctx context.Context, address, url string) Result {
+	var r Result
+	start := time.Now()
+	d := net.Dialer{Timeout: Timeout}
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.Connect = time.Since(start)
+	conn.Close()
+
+	if url == "" {
+		return r
+	}
+	client := &http.Client{
+		Timeout: Timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		r.HTTPErr = err
+		return r
+	}
+	start = time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		r.HTTPErr = err
+		return r
+	}
+	r.Response = time.Since(start)
+	r.Status = resp.StatusCode
+	resp.Body.Close()
+	return r
+}
@@ -0,0 +1,363 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/notes"
+	"github.com/moby/moby/api/types/container"
+)
+
+// completionPoll is how often a one-shot dependency is checked for exit.
+const completionPoll = 500 * time.Millisecond
+
+// UpOptions tune Up.
+type UpOptions struct {
+	// Notes, if set, has the notes of recreated containers moved to their
+	// replacements, as the TUI does when it recreates one.
+	Notes *notes.Store
+	// RemoveOrphans removes the containers of services no longer in the
+	// file. Without it they are only reported.
+	RemoveOrphans bool
+}
+
+// Up creates, recreates and starts containers until the daemon matches the
+// stack, and removes orphans if opts say so. Services start in dependency order, waiting for a
+// dependency to be healthy or to have completed when a dependent asks for
+// it. progress, if set, is told about each step.
+func Up(ctx context.Context, client *docker.Client, st *Stack, opts UpOptions, progress func(string)) error {
+	for _, n := range st.usedNetworks() {
+		if st.ExternalNetwork(n) {
+			continue
+		}
+		name := st.NetworkName(n)
+		created, err := client.EnsureNetwork(ctx, name, map[string]string{LabelProject: st.Name, LabelNetwork: n})
+		if err != nil {
+			return fmt.Errorf("network %s: %w", name, err)
+		}
+		if created {
+			say(progress, "Created network %s", name)
+		}
+	}
+	for _, v := range st.Volumes {
+		if st.ExternalVolume(v) {
+			continue
+		}
+		name := st.VolumeName(v)
+		created, err := client.EnsureVolume(ctx, name, map[string]string{LabelProject: st.Name, LabelVolume: v})
+		if err != nil {
+			return fmt.Errorf("volume %s: %w", name, err)
+		}
+		if created {
+			say(progress, "Created volume %s", name)
+		}
+	}
+
+	containers, err := client.ListContainers(ctx)
+	if err != nil {
+		return err
+	}
+	changes, err := Diff(st, containers, Inspector(ctx, client))
+	if err != nil {
+		return err
+	}
+
+	deps := st.dependencies()
+	for _, ch := range changes {
+		if ch.Action == ActionRemove {
+			if !opts.RemoveOrphans {
+				say(progress, "Found orphan %s, -remove-orphans removes it", ch.Service)
+				continue
+			}
+			say(progress, "Removing orphan %s", ch.Service)
+			if err := stopAndRemove(ctx, client, ch.Container.ID); err != nil {
+				return fmt.Errorf("%s: %w", ch.Service, err)
+			}
+			continue
+		}
+
+		spec, err := st.Spec(ch.Service)
+		if err != nil {
+			return err
+		}
+		id := ""
+		switch ch.Action {
+		case ActionCreate:
+			say(progress, "Creating %s", spec.Name)
+			if id, err = client.Create(ctx, spec); err == nil {
+				err = client.Start(ctx, id)
+			}
+		case ActionRecreate:
+			say(progress, "Recreating %s (%s)", spec.Name, ch.Reason)
+			if id, err = client.Recreate(ctx, ch.Container.ID, spec); err == nil {
+				_ = opts.Notes.Move(ch.Container.ID, id)
+			}
+		case ActionStart:
+			say(progress, "Starting %s", spec.Name)
+			id = ch.Container.ID
+			err = client.Start(ctx, id)
+		case ActionNone:
+			id = ch.Container.ID
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", ch.Service, err)
+		}
+
+		if cond := awaitedCondition(deps, ch.Service); cond != "" {
+			say(progress, "Waiting for %s (%s)", spec.Name, cond)
+			if err := WaitCondition(ctx, client, id, cond); err != nil {
+				return fmt.Errorf("%s: %w", ch.Service, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Inspector is the InspectFunc Diff gets from a client.
+func Inspector(ctx context.Context, client *docker.Client) InspectFunc {
+	return func(id string) (container.InspectResponse, error) {
+		return client.Inspect(ctx, id)
+	}
+}
+
+// Down stops and removes the stack's containers, dependents first, then its
+// networks and, when volumes is set, its volumes.
+func Down(ctx context.Context, client *docker.Client, st *Stack, volumes bool, progress func(string)) error {
+	containers, err := client.ListContainers(ctx)
+	if err != nil {
+		return err
+	}
+	actual := Containers(st.Name, containers)
+	order, err := OrderContainers(actual)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(order)
+	for _, name := range order {
+		c := actual[name]
+		say(progress, "Removing %s", strings.TrimPrefix(c.Names[0], "/"))
+		if err := stopAndRemove(ctx, client, c.ID); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	for _, n := range st.usedNetworks() {
+		if st.ExternalNetwork(n) {
+			continue
+		}
+		if err := client.RemoveNetwork(ctx, st.NetworkName(n)); err != nil {
+			return err
+		}
+	}
+	if volumes {
+		for _, v := range st.Volumes {
+			if st.ExternalVolume(v) {
+				continue
+			}
+			say(progress, "Removing volume %s", st.VolumeName(v))
+			if err := client.RemoveVolume(ctx, st.VolumeName(v)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// OrderContainers sorts a project's containers by service so that each
+// comes after the services it depends on, as recorded in the depends_on
+// label. This works for projects started by Compose as well.
+func OrderContainers(byService map[string]container.Summary) ([]string, error) {
+	return orderServices(projectDeps(byService, nil), byService)
+}
+
+// StartProject starts a project's stopped containers so that each comes
+// after the services it depends on, waiting for a dependency to be healthy
+// or to have completed when a dependent asks for it. Dependencies come from
+// st when it is given and otherwise from the depends_on labels, which
+// Compose sets too.
+func StartProject(ctx context.Context, client *docker.Client, byService map[string]container.Summary, st *Stack, progress func(string)) error {
+	deps := projectDeps(byService, st)
+	order, err := orderServices(deps, byService)
+	if err != nil {
+		return err
+	}
+	for _, name := range order {
+		c := byService[name]
+		cname := strings.TrimPrefix(c.Names[0], "/")
+		if c.State != container.StateRunning {
+			say(progress, "Starting %s", cname)
+			if err := client.Start(ctx, c.ID); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if cond := awaitedCondition(deps, name); cond != "" {
+			say(progress, "Waiting for %s (%s)", cname, cond)
+			if err := WaitCondition(ctx, client, c.ID, cond); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// StopProject stops a project's running containers, dependents before the
+// services they depend on.
+func StopProject(ctx context.Context, client *docker.Client, byService map[string]container.Summary, st *Stack, progress func(string)) error {
+	order, err := orderServices(projectDeps(byService, st), byService)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(order)
+	for _, name := range order {
+		c := byService[name]
+		if c.State != container.StateRunning && c.State != container.StateRestarting {
+			continue
+		}
+		say(progress, "Stopping %s", strings.TrimPrefix(c.Names[0], "/"))
+		if err := client.Stop(ctx, c.ID); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// projectDeps returns the dependencies of each service of a project, from
+// st for the services it defines and from the depends_on label otherwise.
+func projectDeps(byService map[string]container.Summary, st *Stack) map[string][]Dependency {
+	deps := map[string][]Dependency{}
+	for name, c := range byService {
+		if st != nil && st.Services[name] != nil {
+			deps[name] = st.Services[name].DependsOn
+		} else {
+			deps[name] = ParseDependsOn(c.Labels[LabelDependsOn])
+		}
+	}
+	return deps
+}
+
+func orderServices(deps map[string][]Dependency, byService map[string]container.Summary) ([]string, error) {
+	names := make([]string, 0, len(byService))
+	edges := map[string][]string{}
+	for name := range byService {
+		names = append(names, name)
+		for _, d := range deps[name] {
+			edges[name] = append(edges[name], d.Service)
+		}
+	}
+	return Order(edges, names)
+}
+
+// ParseDependsOn reads the depends_on label, a comma separated list of
+// service:condition:restart.
+func ParseDependsOn(label string) []Dependency {
+	var deps []Dependency
+	for _, entry := range strings.Split(label, ",") {
+		parts := strings.Split(entry, ":")
+		if parts[0] == "" {
+			continue
+		}
+		d := Dependency{Service: parts[0], Condition: ConditionStarted}
+		if len(parts) > 1 && parts[1] != "" {
+			d.Condition = parts[1]
+		}
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+// WaitCondition blocks until the container satisfies a depends_on
+// condition.
+func WaitCondition(ctx context.Context, client *docker.Client, id, condition string) error {
+	switch condition {
+	case ConditionHealthy:
+		return client.WaitStarted(ctx, id)
+	case ConditionCompleted:
+		for {
+			ins, err := client.Inspect(ctx, id)
+			if err != nil {
+				return err
+			}
+			if st := ins.State; st != nil && (st.Status == container.StateExited || st.Status == container.StateDead) {
+				if st.ExitCode != 0 {
+					return fmt.Errorf("exited with code %d", st.ExitCode)
+				}
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(completionPoll):
+			}
+		}
+	}
+	return nil
+}
+
+// awaitedCondition is the strongest condition other services put on
+// service, "" when they only need it started.
+func awaitedCondition(deps map[string][]Dependency, service string) string {
+	cond := ""
+	for _, ds := range deps {
+		for _, d := range ds {
+			if d.Service != service {
+				continue
+			}
+			switch d.Condition {
+			case ConditionCompleted:
+				return ConditionCompleted
+			case ConditionHealthy:
+				cond = ConditionHealthy
+			}
+		}
+	}
+	return cond
+}
+
+// dependencies returns the depends_on of every service.
+func (st *Stack) dependencies() map[string][]Dependency {
+	deps := map[string][]Dependency{}
+	for name, svc := range st.Services {
+		deps[name] = svc.DependsOn
+	}
+	return deps
+}
+
+// usedNetworks lists the declared networks some service joins.
+func (st *Stack) usedNetworks() []string {
+	used := map[string]bool{}
+	for _, svc := range st.Services {
+		if svc.NetworkMode != "" {
+			continue
+		}
+		if len(svc.Networks) == 0 {
+			used[DefaultNetwork] = true
+		}
+		for _, n := range svc.Networks {
+			used[n] = true
+		}
+	}
+	var out []string
+	for n := range used {
+		out = append(out, n)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// say reports a step to progress, if set.
+func say(progress func(string), format string, args ...any) {
+	if progress != nil {
+		progress(fmt.Sprintf(format, args...))
+	}
+}
+
+func stopAndRemove(ctx context.Context, client *docker.Client, id string) error {
+	if err := client.Stop(ctx, id); err != nil {
+		return err
+	}
+	return client.Remove(ctx, id)
+}
@@ -0,0 +1,899 @@
+package stack
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsComposeFile tells a Compose file from a stack file by its name:
+// compose.yaml, docker-compose.yml and variants like compose.prod.yaml.
+func IsComposeFile(path string) bool {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	if ext != ".yaml" && ext != ".yml" {
+		return false
+	}
+	stem := strings.TrimSuffix(base, ext)
+	return stem == "compose" || stem == "docker-compose" ||
+		strings.HasPrefix(stem, "compose.") || strings.HasPrefix(stem, "docker-compose.")
+}
+
+// composeFile is the subset of the Compose specification stackr
+// understands. Anything else ends up in Other and is reported as a
+// warning rather than silently dropped.
+type composeFile struct {
+	Name     string                      `yaml:"name"`
+	Services map[string]*composeService  `yaml:"services"`
+	Networks map[string]*composeResource `yaml:"networks"`
+	Volumes  map[string]*composeResource `yaml:"volumes"`
+	Version  string                      `yaml:"version"` // obsolete, ignored
+	Other    map[string]any              `yaml:",inline"`
+}
+
+type composeResource struct {
+	Name     string          `yaml:"name"`
+	External composeExternal `yaml:"external"`
+	Other    map[string]any  `yaml:",inline"`
+}
+
+// composeExternal accepts external: true as well as the legacy
+// external: {name: ...} form.
+type composeExternal struct {
+	External bool
+	Name     string
+}
+
+func (e *composeExternal) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.MappingNode {
+		var legacy struct {
+			Name string `yaml:"name"`
+		}
+		if err := n.Decode(&legacy); err != nil {
+			return err
+		}
+		*e = composeExternal{External: true, Name: legacy.Name}
+		return nil
+	}
+	return n.Decode(&e.External)
+}
+
+type composeService struct {
+	Image         string          `yaml:"image"`
+	ContainerName string          `yaml:"container_name"`
+	Entrypoint    Command         `yaml:"entrypoint"`
+	Command       Command         `yaml:"command"`
+	Environment   composeMapping  `yaml:"environment"`
+	EnvFile       composeEnvFiles `yaml:"env_file"`
+	Ports         []composePort   `yaml:"ports"`
+	Volumes       []composeVolume `yaml:"volumes"`
+	Networks      composeNetworks `yaml:"networks"`
+	NetworkMode   string          `yaml:"network_mode"`
+	DependsOn     Dependencies    `yaml:"depends_on"`
+	Restart       string          `yaml:"restart"`
+	MemLimit      string          `yaml:"mem_limit"`
+	CPUs          string          `yaml:"cpus"`
+	Deploy        *composeDeploy  `yaml:"deploy"`
+	Labels        Mapping         `yaml:"labels"`
+	Healthcheck   *Healthcheck    `yaml:"healthcheck"`
+	Profiles      []string        `yaml:"profiles"`
+	Extends       *composeExtends `yaml:"extends"`
+	Other         map[string]any  `yaml:",inline"`
+}
+
+type composeDeploy struct {
+	Resources struct {
+		Limits struct {
+			Memory string `yaml:"memory"`
+			CPUs   string `yaml:"cpus"`
+		} `yaml:"limits"`
+	} `yaml:"resources"`
+}
+
+type composeExtends struct {
+	Service string `yaml:"service"`
+	File    string `yaml:"file"`
+}
+
+// composeMapping is like Mapping but keeps entries without a value apart,
+// Compose fills those from the environment.
+type composeMapping map[string]*string
+
+func (m *composeMapping) UnmarshalYAML(n *yaml.Node) error {
+	out := composeMapping{}
+	switch n.Kind {
+	case yaml.MappingNode:
+		raw := map[string]*string{}
+		if err := n.Decode(&raw); err != nil {
+			return err
+		}
+		for k, v := range raw {
+			out[k] = v
+		}
+	case yaml.SequenceNode:
+		var list []string
+		if err := n.Decode(&list); err != nil {
+			return err
+		}
+		for _, kv := range list {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				out[k] = &v
+			} else {
+				out[k] = nil
+			}
+		}
+	default:
+		return fmt.Errorf("line %d: expected a map or a list of KEY=value", n.Line)
+	}
+	*m = out
+	return nil
+}
+
+type composeEnvFile struct {
+	Path     string `yaml:"path"`
+	Required bool   `yaml:"required"`
+}
+
+// composeEnvFiles accepts a path, a list of paths or a list of
+// {path, required}.
+type composeEnvFiles []composeEnvFile
+
+func (f *composeEnvFiles) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*f = composeEnvFiles{{Path: n.Value, Required: true}}
+		return nil
+	}
+	if n.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: expected a path or a list of paths", n.Line)
+	}
+	var out composeEnvFiles
+	for _, item := range n.Content {
+		e := composeEnvFile{Path: item.Value, Required: true}
+		if item.Kind == yaml.MappingNode {
+			if err := item.Decode(&e); err != nil {
+				return err
+			}
+		}
+		out = append(out, e)
+	}
+	*f = out
+	return nil
+}
+
+// composePort converts the long port syntax to the short one docker run
+// takes.
+type composePort string
+
+func (p *composePort) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*p = composePort(n.Value)
+		return nil
+	}
+	var long struct {
+		Target    string `yaml:"target"`
+		Published string `yaml:"published"`
+		HostIP    string `yaml:"host_ip"`
+		Protocol  string `yaml:"protocol"`
+	}
+	if err := n.Decode(&long); err != nil {
+		return err
+	}
+	if long.Target == "" {
+		return fmt.Errorf("line %d: port target is required", n.Line)
+	}
+	s := long.Target
+	if long.Published != "" {
+		s = long.Published + ":" + s
+		if long.HostIP != "" {
+			s = long.HostIP + ":" + s
+		}
+	}
+	if long.Protocol != "" {
+		s += "/" + long.Protocol
+	}
+	*p = composePort(s)
+	return nil
+}
+
+// composeVolume converts the long volume syntax to source:target[:ro].
+type composeVolume string
+
+func (v *composeVolume) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*v = composeVolume(n.Value)
+		return nil
+	}
+	var long struct {
+		Type     string `yaml:"type"`
+		Source   string `yaml:"source"`
+		Target   string `yaml:"target"`
+		ReadOnly bool   `yaml:"read_only"`
+	}
+	if err := n.Decode(&long); err != nil {
+		return err
+	}
+	switch long.Type {
+	case "bind", "volume", "":
+	default:
+		return fmt.Errorf("line %d: %s mounts are not supported", n.Line, long.Type)
+	}
+	s := long.Source + ":" + long.Target
+	if long.ReadOnly {
+		s += ":ro"
+	}
+	*v = composeVolume(s)
+	return nil
+}
+
+// composeNetworks accepts a list of networks or a map of network to
+// {aliases}.
+type composeNetworks struct {
+	Names   []string
+	Aliases map[string][]string
+}
+
+func (c *composeNetworks) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		return n.Decode(&c.Names)
+	case yaml.MappingNode:
+		var raw map[string]*struct {
+			Aliases []string `yaml:"aliases"`
+		}
+		if err := n.Decode(&raw); err != nil {
+			return err
+		}
+		c.Aliases = map[string][]string{}
+		for name, opts := range raw {
+			c.Names = append(c.Names, name)
+			if opts != nil {
+				c.Aliases[name] = opts.Aliases
+			}
+		}
+		sort.Strings(c.Names)
+		return nil
+	}
+	return fmt.Errorf("line %d: expected a list or a map of networks", n.Line)
+}
+
+// composeLoader resolves interpolation and extends across files.
+type composeLoader struct {
+	env   map[string]string
+	files map[string]*composeFile
+}
+
+// LoadCompose reads a Compose file into a stack. Variables are
+// interpolated from the environment and the .env file next to it, extends
+// is resolved, and services outside the enabled profiles (plus
+// COMPOSE_PROFILES) are left out. Containers created from the result carry
+// the labels Compose uses, so docker compose can manage them too.
+func LoadCompose(path string, profiles []string) (*Stack, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(abs)
+
+	env, err := readEnvFile(filepath.Join(dir, ".env"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if env == nil {
+		env = map[string]string{}
+	}
+	// The shell wins over .env, as with Compose.
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+
+	l := &composeLoader{env: env, files: map[string]*composeFile{}}
+	f, err := l.file(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	name := f.Name
+	if name == "" {
+		name = env["COMPOSE_PROJECT_NAME"]
+	}
+	if name == "" {
+		name = projectName(filepath.Base(dir))
+	}
+	st := &Stack{
+		Name:         name,
+		Services:     map[string]*Service{},
+		File:         abs,
+		networkNames: map[string]string{},
+		volumeNames:  map[string]string{},
+		external:     map[string]bool{},
+	}
+	st.Warnings = unsupported("", f.Other)
+
+	for _, n := range sortedResources(f.Networks) {
+		st.Networks = append(st.Networks, n)
+		if r := f.Networks[n]; r != nil {
+			st.Warnings = append(st.Warnings, unsupported("network "+n, r.Other)...)
+			st.declare("network:"+n, st.networkNames, n, r)
+		}
+	}
+	for _, v := range sortedResources(f.Volumes) {
+		st.Volumes = append(st.Volumes, v)
+		if r := f.Volumes[v]; r != nil {
+			st.Warnings = append(st.Warnings, unsupported("volume "+v, r.Other)...)
+			st.declare("volume:"+v, st.volumeNames, v, r)
+		}
+	}
+
+	enabled := activeProfiles(profiles, env["COMPOSE_PROFILES"])
+	for svcName := range f.Services {
+		cs, err := l.service(abs, svcName, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if !enabled(cs.Profiles) {
+			st.Disabled = append(st.Disabled, svcName)
+			continue
+		}
+		svc, err := l.convert(svcName, cs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: service %s: %w", path, svcName, err)
+		}
+		st.Services[svcName] = svc
+		st.Warnings = append(st.Warnings, unsupported("service "+svcName, cs.Other)...)
+	}
+	for _, svcName := range st.ServiceNames() {
+		for _, d := range st.Services[svcName].DependsOn {
+			if st.Services[d.Service] == nil && f.Services[d.Service] != nil {
+				return nil, fmt.Errorf("%s: service %s depends on %s, which no enabled profile includes", path, svcName, d.Service)
+			}
+		}
+	}
+	sort.Strings(st.Warnings)
+	sort.Strings(st.Disabled)
+
+	if err := st.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return st, nil
+}
+
+// declare records an explicit or external name for a network or volume.
+func (st *Stack) declare(key string, names map[string]string, short string, r *composeResource) {
+	if r.External.External {
+		st.external[key] = true
+		names[short] = short
+	}
+	if r.External.Name != "" {
+		names[short] = r.External.Name
+	}
+	if r.Name != "" {
+		names[short] = r.Name
+	}
+}
+
+// file parses and interpolates a Compose file, once.
+func (l *composeLoader) file(path string) (*composeFile, error) {
+	if f, ok := l.files[path]; ok {
+		return f, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := interpolateNode(&doc, l.env); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var f composeFile
+	if err := doc.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	for _, s := range f.Services {
+		if s != nil {
+			s.rebase(dir)
+		}
+	}
+	l.files[path] = &f
+	return &f, nil
+}
+
+// service returns a service of the file at path with extends resolved.
+// chain guards against a service extending itself.
+func (l *composeLoader) service(path, name string, chain []string) (*composeService, error) {
+	ref := path + "#" + name
+	if slices.Contains(chain, ref) {
+		return nil, fmt.Errorf("service %s extends itself", name)
+	}
+	f, err := l.file(path)
+	if err != nil {
+		return nil, err
+	}
+	s := f.Services[name]
+	if s == nil {
+		if _, ok := f.Services[name]; !ok {
+			return nil, fmt.Errorf("no service %s in %s", name, path)
+		}
+		s = &composeService{}
+	}
+	if s.Extends == nil {
+		return s, nil
+	}
+
+	basePath := path
+	if s.Extends.File != "" {
+		basePath = s.Extends.File
+		if !filepath.IsAbs(basePath) {
+			basePath = filepath.Join(filepath.Dir(path), basePath)
+		}
+	}
+	base, err := l.service(basePath, s.Extends.Service, append(chain, ref))
+	if err != nil {
+		return nil, fmt.Errorf("service %s: extends: %w", name, err)
+	}
+	return base.merge(s), nil
+}
+
+// rebase makes relative paths absolute against the directory of the file
+// the service is defined in, so they survive extends across directories.
+func (s *composeService) rebase(dir string) {
+	for i, e := range s.EnvFile {
+		if !filepath.IsAbs(e.Path) {
+			s.EnvFile[i].Path = filepath.Join(dir, e.Path)
+		}
+	}
+	for i, v := range s.Volumes {
+		src, rest, ok := strings.Cut(string(v), ":")
+		if !ok || isNamedVolume(src) || filepath.IsAbs(src) {
+			continue
+		}
+		if src == "~" || strings.HasPrefix(src, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				src = filepath.Join(home, src[1:])
+			}
+		} else {
+			src = filepath.Join(dir, src)
+		}
+		s.Volumes[i] = composeVolume(src + ":" + rest)
+	}
+}
+
+// merge applies override on top of a copy of s, the way extends does:
+// scalars and commands are replaced, mappings are merged, and ports,
+// volumes and env files add up.
+func (s *composeService) merge(override *composeService) *composeService {
+	out := *s
+	o := override
+	out.Extends = nil
+	if o.Image != "" {
+		out.Image = o.Image
+	}
+	if o.ContainerName != "" {
+		out.ContainerName = o.ContainerName
+	}
+	if o.Entrypoint != nil {
+		out.Entrypoint = o.Entrypoint
+	}
+	if o.Command != nil {
+		out.Command = o.Command
+	}
+	if o.NetworkMode != "" {
+		out.NetworkMode = o.NetworkMode
+	}
+	if o.Restart != "" {
+		out.Restart = o.Restart
+	}
+	if o.MemLimit != "" {
+		out.MemLimit = o.MemLimit
+	}
+	if o.CPUs != "" {
+		out.CPUs = o.CPUs
+	}
+	if o.Deploy != nil {
+		out.Deploy = o.Deploy
+	}
+	if o.Healthcheck != nil {
+		out.Healthcheck = o.Healthcheck
+	}
+	if o.Networks.Names != nil {
+		out.Networks = o.Networks
+	}
+	if o.Profiles != nil {
+		out.Profiles = o.Profiles
+	}
+
+	out.Environment = composeMapping{}
+	for k, v := range s.Environment {
+		out.Environment[k] = v
+	}
+	for k, v := range o.Environment {
+		out.Environment[k] = v
+	}
+	out.Labels = Mapping{}
+	for k, v := range s.Labels {
+		out.Labels[k] = v
+	}
+	for k, v := range o.Labels {
+		out.Labels[k] = v
+	}
+
+	out.EnvFile = append(slices.Clone(s.EnvFile), o.EnvFile...)
+	out.Ports = append(slices.Clone(s.Ports), o.Ports...)
+	out.DependsOn = append(slices.Clone(s.DependsOn), o.DependsOn...)
+	// A volume mounted on the same target is overridden.
+	out.Volumes = nil
+	for _, v := range s.Volumes {
+		if !slices.ContainsFunc(o.Volumes, func(ov composeVolume) bool { return ov.target() == v.target() }) {
+			out.Volumes = append(out.Volumes, v)
+		}
+	}
+	out.Volumes = append(out.Volumes, o.Volumes...)
+
+	out.Other = map[string]any{}
+	for k, v := range s.Other {
+		out.Other[k] = v
+	}
+	for k, v := range o.Other {
+		out.Other[k] = v
+	}
+	return &out
+}
+
+func (v composeVolume) target() string {
+	parts := strings.Split(string(v), ":")
+	if len(parts) > 1 {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+// convert turns a resolved Compose service into a stack service.
+func (l *composeLoader) convert(name string, cs *composeService) (*Service, error) {
+	if cs.Image == "" {
+		if _, ok := cs.Other["build"]; ok {
+			return nil, fmt.Errorf("build is not supported, set an image")
+		}
+		return nil, fmt.Errorf("image is required")
+	}
+	svc := &Service{
+		Image:         cs.Image,
+		ContainerName: cs.ContainerName,
+		Entrypoint:    cs.Entrypoint,
+		Command:       cs.Command,
+		Environment:   Mapping{},
+		NetworkMode:   cs.NetworkMode,
+		Networks:      cs.Networks.Names,
+		DependsOn:     cs.DependsOn,
+		Restart:       cs.Restart,
+		Memory:        cs.MemLimit,
+		CPUs:          cs.CPUs,
+		Labels:        cs.Labels,
+		Healthcheck:   cs.Healthcheck,
+	}
+	if d := cs.Deploy; d != nil {
+		if m := d.Resources.Limits.Memory; m != "" {
+			svc.Memory = m
+		}
+		if c := d.Resources.Limits.CPUs; c != "" {
+			svc.CPUs = c
+		}
+	}
+	if svc.Restart == "no" {
+		svc.Restart = ""
+	}
+
+	// env_file first, environment on top; a bare KEY takes its value from
+	// the shell and is left out when that doesn't set it either.
+	for _, e := range cs.EnvFile {
+		vars, err := readEnvFile(e.Path)
+		if err != nil {
+			if os.IsNotExist(err) && !e.Required {
+				continue
+			}
+			return nil, err
+		}
+		for k, v := range vars {
+			svc.Environment[k] = v
+		}
+	}
+	for k, v := range cs.Environment {
+		switch {
+		case v != nil:
+			svc.Environment[k] = *v
+		default:
+			if val, ok := l.env[k]; ok {
+				svc.Environment[k] = val
+			}
+		}
+	}
+
+	for _, p := range cs.Ports {
+		svc.Ports = append(svc.Ports, string(p))
+	}
+	for _, v := range cs.Volumes {
+		if !strings.Contains(string(v), ":") {
+			return nil, fmt.Errorf("anonymous volume %s is not supported, name it", v)
+		}
+		svc.Volumes = append(svc.Volumes, string(v))
+	}
+	for _, n := range cs.Networks.Names {
+		for _, a := range cs.Networks.Aliases[n] {
+			if !slices.Contains(svc.Aliases, a) {
+				svc.Aliases = append(svc.Aliases, a)
+			}
+		}
+	}
+
+	// Dependencies may be listed twice after extends; keep the last.
+	seen := map[string]int{}
+	var deps Dependencies
+	for _, d := range svc.DependsOn {
+		if i, ok := seen[d.Service]; ok {
+			deps[i] = d
+			continue
+		}
+		seen[d.Service] = len(deps)
+		deps = append(deps, d)
+	}
+	svc.DependsOn = deps
+	return svc, nil
+}
+
+// unsupported lists the keys stackr ignores, extensions (x-) aside.
+func unsupported(what string, other map[string]any) []string {
+	var out []string
+	for k := range other {
+		if strings.HasPrefix(k, "x-") || k == "build" {
+			continue
+		}
+		if what == "" {
+			out = append(out, fmt.Sprintf("top-level %s is not supported, ignored", k))
+		} else {
+			out = append(out, fmt.Sprintf("%s: %s is not supported, ignored", what, k))
+		}
+	}
+	return out
+}
+
+func sortedResources(m map[string]*composeResource) []string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// activeProfiles returns a predicate telling whether a service with the
+// given profiles is enabled. Services without profiles always are, "*"
+// enables everything.
+func activeProfiles(flags []string, env string) func([]string) bool {
+	active := map[string]bool{}
+	for _, p := range append(flags, strings.Split(env, ",")...) {
+		if p = strings.TrimSpace(p); p != "" {
+			active[p] = true
+		}
+	}
+	return func(profiles []string) bool {
+		if len(profiles) == 0 || active["*"] {
+			return true
+		}
+		for _, p := range profiles {
+			if active[p] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var projectNameStrip = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// projectName derives a project name from a directory name like Compose
+// does: lowercased, with anything else than letters, digits, - and _
+// removed, and starting with a letter or digit.
+func projectName(dir string) string {
+	name := projectNameStrip.ReplaceAllString(strings.ToLower(dir), "")
+	return strings.TrimLeft(name, "_-")
+}
+
+// readEnvFile parses KEY=value lines, skipping blanks and comments. Values
+// may be quoted and lines may start with export.
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		k, v, ok := strings.Cut(text, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", path, line)
+		}
+		v = strings.TrimSpace(v)
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			if v[0] == '"' {
+				if u, err := strconv.Unquote(v); err == nil {
+					v = u
+				} else {
+					v = v[1 : len(v)-1]
+				}
+			} else {
+				v = v[1 : len(v)-1]
+			}
+		} else if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		vars[k] = v
+	}
+	return vars, sc.Err()
+}
+
+// interpolateNode substitutes variables in every scalar value of the
+// document. Keys are left alone, as in Compose.
+func interpolateNode(n *yaml.Node, env map[string]string) error {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			if err := interpolateNode(c, env); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			if err := interpolateNode(n.Content[i], env); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		v, err := interpolate(n.Value, env)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		if v != n.Value {
+			n.Value = v
+			// Let a plain ${PORT} resolve to an int again.
+			if n.Style == 0 {
+				n.Tag = ""
+			}
+		}
+	}
+	return nil
+}
+
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+// interpolate expands $VAR, ${VAR}, ${VAR:-default}, ${VAR-default},
+// ${VAR:?error}, ${VAR?error}, ${VAR:+alt} and ${VAR+alt}. $$ is a
+// literal $.
+func interpolate(s string, env map[string]string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			v, err := expand(s[i+2:end], env)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			i = end
+		default:
+			name := varName.FindString(s[i+1:])
+			if name == "" {
+				b.WriteByte('$')
+				continue
+			}
+			b.WriteString(env[name])
+			i += len(name)
+		}
+	}
+	return b.String(), nil
+}
+
+// closingBrace finds the } matching an opening one just before from,
+// skipping nested ${...}.
+func closingBrace(s string, from int) int {
+	depth := 0
+	for i := from; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// expand evaluates the inside of ${...}.
+func expand(expr string, env map[string]string) (string, error) {
+	name := varName.FindString(expr)
+	if name == "" {
+		return "", fmt.Errorf("invalid variable ${%s}", expr)
+	}
+	op, arg := expr[len(name):], ""
+	value, set := env[name]
+	for _, o := range []string{":-", ":?", ":+", "-", "?", "+"} {
+		if strings.HasPrefix(op, o) {
+			op, arg = o, op[len(o):]
+			break
+		}
+	}
+	nonEmpty := set && value != ""
+	switch op {
+	case "":
+		return value, nil
+	case ":-":
+		if nonEmpty {
+			return value, nil
+		}
+		return interpolate(arg, env)
+	case "-":
+		if set {
+			return value, nil
+		}
+		return interpolate(arg, env)
+	case ":?", "?":
+		if (op == ":?" && nonEmpty) || (op == "?" && set) {
+			return value, nil
+		}
+		msg, err := interpolate(arg, env)
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			return "", fmt.Errorf("required variable %s is missing a value", name)
+		}
+		return "", fmt.Errorf("required variable %s is missing a value: %s", name, msg)
+	case ":+":
+		if nonEmpty {
+			return interpolate(arg, env)
+		}
+		return "", nil
+	case "+":
+		if set {
+			return interpolate(arg, env)
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("invalid variable ${%s}", expr)
+}
@@ -0,0 +1,214 @@
+package stack
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"A": "a", "EMPTY": "", "PORT": "8080"}
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"$A and ${A}", "a and a"},
+		{"$$A", "$A"},
+		{"cost: 5$", "cost: 5$"},
+		{"$1", "$1"},
+		{"${UNSET}", ""},
+		{"${UNSET:-def}", "def"},
+		{"${EMPTY:-def}", "def"},
+		{"${UNSET-def}", "def"},
+		{"${EMPTY-def}", ""},
+		{"${A:-def}", "a"},
+		{"${A:+alt}", "alt"},
+		{"${EMPTY:+alt}", ""},
+		{"${EMPTY+alt}", "alt"},
+		{"${UNSET+alt}", ""},
+		{"${EMPTY?}", ""},
+		{"${UNSET:-${A}-x}", "a-x"},
+		{"${UNSET:-${ALSO_UNSET:-${PORT}}}", "8080"},
+		{"${UNSET:-$$}", "$"},
+		{"127.0.0.1:${PORT}:80", "127.0.0.1:8080:80"},
+	}
+	for _, tt := range tests {
+		got, err := interpolate(tt.in, env)
+		if err != nil || got != tt.want {
+			t.Errorf("interpolate(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		"${UNSET:?}",
+		"${EMPTY:?must be set}",
+		"${UNSET?}",
+		"${A",
+		"${-def}",
+		"${A!}",
+	} {
+		if got, err := interpolate(in, env); err == nil {
+			t.Errorf("interpolate(%q) = %q, want an error", in, got)
+		}
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	err := os.WriteFile(path, []byte(`# comment
+
+export A=1
+B = two
+C="say \"hi\"\n"
+D='single $x \n'
+E=value # comment
+F=
+G="hash # kept"
+H=a=b
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"A": "1",
+		"B": "two",
+		"C": "say \"hi\"\n",
+		"D": `single $x \n`,
+		"E": "value",
+		"F": "",
+		"G": "hash # kept",
+		"H": "a=b",
+	}
+	if len(got) != len(want) {
+		t.Errorf("vars = %q, want %q", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("A=1\nNOT A VARIABLE\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readEnvFile(path); err == nil {
+		t.Error("no error for a line without =")
+	}
+}
+
+func TestLoadComposeExtends(t *testing.T) {
+	dir := t.TempDir()
+	common := filepath.Join(dir, "common")
+	if err := os.Mkdir(common, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(common, "base.yaml"): `services:
+  base:
+    image: app:1
+    command: ["serve"]
+    restart: always
+    environment:
+      A: "1"
+      B: "1"
+    labels:
+      tier: web
+    ports: ["80"]
+    volumes:
+      - ./cache:/cache
+      - ./data:/data
+      - logs:/logs
+`,
+		filepath.Join(dir, "compose.yaml"): `name: shop
+services:
+  web:
+    extends:
+      file: common/base.yaml
+      service: base
+    command: ["serve", "--debug"]
+    environment:
+      - B=2
+      - C=3
+    labels:
+      team: shop
+    ports: ["443"]
+    volumes:
+      - ./other:/data
+  admin:
+    extends: {service: web}
+    image: app:2
+    restart: "no"
+volumes:
+  logs:
+`,
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st, err := LoadCompose(filepath.Join(dir, "compose.yaml"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := st.Services["web"]
+	if web == nil {
+		t.Fatalf("services = %v, want web", st.ServiceNames())
+	}
+
+	// Scalars and commands are replaced, mappings merged.
+	if web.Image != "app:1" || web.Restart != "always" || !slices.Equal(web.Command, Command{"serve", "--debug"}) {
+		t.Errorf("image, restart, command = %q, %q, %q", web.Image, web.Restart, web.Command)
+	}
+	if want := (Mapping{"A": "1", "B": "2", "C": "3"}); !maps.Equal(web.Environment, want) {
+		t.Errorf("environment = %v, want %v", web.Environment, want)
+	}
+	if want := (Mapping{"tier": "web", "team": "shop"}); !maps.Equal(web.Labels, want) {
+		t.Errorf("labels = %v, want %v", web.Labels, want)
+	}
+	// Ports add up; a volume on the same target is overridden, and
+	// relative paths are relative to the file that declares them.
+	if want := []string{"80", "443"}; !slices.Equal(web.Ports, want) {
+		t.Errorf("ports = %q, want %q", web.Ports, want)
+	}
+	wantVolumes := []string{
+		filepath.Join(common, "cache") + ":/cache",
+		"logs:/logs",
+		filepath.Join(dir, "other") + ":/data",
+	}
+	if !slices.Equal(web.Volumes, wantVolumes) {
+		t.Errorf("volumes = %q, want %q", web.Volumes, wantVolumes)
+	}
+
+	// Extends chains, within the file too.
+	admin := st.Services["admin"]
+	if admin == nil || admin.Image != "app:2" || admin.Restart != "" || !slices.Equal(admin.Ports, []string{"80", "443"}) {
+		t.Errorf("admin = %+v, want web's with image app:2 and no restart", admin)
+	}
+}
+
+func TestLoadComposeExtendsItself(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compose.yaml")
+	err := os.WriteFile(path, []byte(`services:
+  a:
+    image: app
+    extends: {service: b}
+  b:
+    image: app
+    extends: {service: a}
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCompose(path, nil); err == nil {
+		t.Error("no error for services extending each other")
+	}
+}
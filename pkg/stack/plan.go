@@ -0,0 +1,143 @@
+package stack
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/moby/moby/api/types/container"
+)
+
+// Action is what converging a service requires.
+type Action string
+
+const (
+	ActionNone     Action = "none"
+	ActionCreate   Action = "create"
+	ActionRecreate Action = "recreate"
+	ActionStart    Action = "start"
+	ActionRemove   Action = "remove"
+)
+
+// Change is the state of one service compared with the stack file.
+type Change struct {
+	Service string
+	Action  Action
+	Reason  string
+	// Container is the existing container, nil for ActionCreate.
+	Container *container.Summary
+}
+
+// InspectFunc looks up a container, for what Diff can't tell from its
+// summary.
+type InspectFunc func(id string) (container.InspectResponse, error)
+
+// Containers returns the containers belonging to the project, by service.
+func Containers(project string, containers []container.Summary) map[string]container.Summary {
+	out := map[string]container.Summary{}
+	for _, c := range containers {
+		if c.Labels[LabelProject] == project && c.Labels[LabelOneoff] != "True" {
+			out[c.Labels[LabelService]] = c
+		}
+	}
+	return out
+}
+
+// Diff compares the stack with the containers on the daemon. Changes come
+// in dependency order, orphans (containers of the project whose service is
+// no longer in the file) last. Containers of services disabled by their
+// profiles are left out.
+//
+// Containers docker compose created carry its own hash instead of stackr's,
+// which stackr can't compute. Their image, environment and ports are
+// compared with the file instead, through inspect; they are only recreated,
+// as stackr's from then on, when one of those changed. It doesn't go the
+// other way: docker compose recreates the containers stackr made, having
+// no hash of its own to find on them.
+func Diff(st *Stack, containers []container.Summary, inspect InspectFunc) ([]Change, error) {
+	order, err := st.Order()
+	if err != nil {
+		return nil, err
+	}
+	actual := Containers(st.Name, containers)
+	for _, name := range st.Disabled {
+		delete(actual, name)
+	}
+
+	var changes []Change
+	for _, name := range order {
+		spec, err := st.Spec(name)
+		if err != nil {
+			return nil, err
+		}
+		c, ok := actual[name]
+		if !ok {
+			changes = append(changes, Change{Service: name, Action: ActionCreate, Reason: "missing"})
+			continue
+		}
+		delete(actual, name)
+
+		change := Change{Service: name, Action: ActionNone, Reason: string(c.State), Container: &c}
+		hash := c.Labels[LabelConfigHash]
+		switch {
+		case hash == "" && c.Labels[LabelComposeHash] != "":
+			ins, err := inspect(c.ID)
+			if err != nil {
+				return nil, err
+			}
+			drift, err := docker.Drift(spec, ins)
+			if err != nil {
+				return nil, err
+			}
+			if drift != "" {
+				change.Action = ActionRecreate
+				change.Reason = drift + " since Compose created it"
+				break
+			}
+			if c.State != container.StateRunning {
+				change.Action = ActionStart
+			}
+			change.Reason += ", created by Compose"
+		case hash != specHash(spec.Labels):
+			change.Action = ActionRecreate
+			change.Reason = "configuration changed"
+			if hash == "" {
+				change.Reason = "not created by stackr"
+			}
+		case c.State != container.StateRunning:
+			change.Action = ActionStart
+		}
+		changes = append(changes, change)
+	}
+
+	orphans := make([]string, 0, len(actual))
+	for name := range actual {
+		orphans = append(orphans, name)
+	}
+	sort.Strings(orphans)
+	for _, name := range orphans {
+		c := actual[name]
+		changes = append(changes, Change{Service: name, Action: ActionRemove, Reason: "not in the stack file", Container: &c})
+	}
+	return changes, nil
+}
+
+// Drifted counts the changes that need an action.
+func Drifted(changes []Change) int {
+	n := 0
+	for _, c := range changes {
+		if c.Action != ActionNone {
+			n++
+		}
+	}
+	return n
+}
+
+func specHash(labels []string) string {
+	for _, l := range labels {
+		if v, ok := strings.CutPrefix(l, LabelConfigHash+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package stack
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+)
+
+func TestDiffComposeProject(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "compose.yaml")
+	err := os.WriteFile(file, []byte(`name: shop
+services:
+  web:
+    image: nginx:1.27
+    ports: ["127.0.0.1:8080:80"]
+    environment:
+      MODE: prod
+  worker:
+    image: busybox
+  cache:
+    image: redis:7
+  debug:
+    image: busybox
+    profiles: [debug]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := LoadCompose(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctr := func(service string, state container.ContainerState, labels map[string]string) container.Summary {
+		l := map[string]string{LabelProject: "shop", LabelService: service}
+		for k, v := range labels {
+			l[k] = v
+		}
+		return container.Summary{ID: service, State: state, Labels: l}
+	}
+	composeHash := map[string]string{LabelComposeHash: "0123abcd"}
+	containers := []container.Summary{
+		ctr("web", container.StateRunning, composeHash),
+		ctr("worker", container.StateExited, composeHash),
+		ctr("cache", container.StateRunning, composeHash),
+		ctr("debug", container.StateRunning, composeHash),
+		ctr("legacy", container.StateRunning, composeHash),
+	}
+	port := network.MustParsePort("80/tcp")
+	inspects := map[string]container.InspectResponse{
+		"web": {
+			Config: &container.Config{Image: "nginx:1.27", Env: []string{"PATH=/usr/bin", "MODE=prod"}},
+			HostConfig: &container.HostConfig{PortBindings: network.PortMap{
+				port: {{HostIP: netip.MustParseAddr("127.0.0.1"), HostPort: "8080"}},
+			}},
+		},
+		"worker": {Config: &container.Config{Image: "busybox"}, HostConfig: &container.HostConfig{}},
+		// The file moved it to a newer Redis since Compose created it.
+		"cache": {Config: &container.Config{Image: "redis:6"}, HostConfig: &container.HostConfig{}},
+	}
+	inspect := func(id string) (container.InspectResponse, error) {
+		ins, ok := inspects[id]
+		if !ok {
+			return ins, fmt.Errorf("no such container %s", id)
+		}
+		return ins, nil
+	}
+
+	changes, err := Diff(st, containers, inspect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]Action{}
+	for _, ch := range changes {
+		got[ch.Service] = ch.Action
+	}
+	// Compose made web and worker, they are not recreated for lacking
+	// stackr's hash, but cache runs another image than the file's. debug's
+	// profile is not enabled, it is no orphan.
+	want := map[string]Action{
+		"web":    ActionNone,
+		"worker": ActionStart,
+		"cache":  ActionRecreate,
+		"legacy": ActionRemove,
+	}
+	if len(got) != len(want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+	for service, action := range want {
+		if got[service] != action {
+			t.Errorf("%s: %s, want %s", service, got[service], action)
+		}
+	}
+}
@@ -0,0 +1,565 @@
+// Package stack manages groups of containers described in a stack file and
+// converges the daemon towards them.
+package stack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/moby/moby/api/types/container"
+	"gopkg.in/yaml.v3"
+)
+
+// Containers are labelled the way Compose labels them so either tool
+// recognises the other's containers, plus a hash of the configuration
+// stackr created them from to detect drift.
+const (
+	LabelProject     = "com.docker.compose.project"
+	LabelService     = "com.docker.compose.service"
+	LabelNumber      = "com.docker.compose.container-number"
+	LabelOneoff      = "com.docker.compose.oneoff"
+	LabelConfigFiles = "com.docker.compose.project.config_files"
+	LabelWorkingDir  = "com.docker.compose.project.working_dir"
+	LabelDependsOn   = "com.docker.compose.depends_on"
+	LabelNetwork     = "com.docker.compose.network"
+	LabelVolume      = "com.docker.compose.volume"
+	LabelConfigHash  = "dev.stackr.config-hash"
+	// LabelComposeHash is the configuration hash docker compose sets. It
+	// can't be compared with stackr's.
+	LabelComposeHash = "com.docker.compose.config-hash"
+)
+
+// Dependency conditions, as in Compose.
+const (
+	ConditionStarted   = "service_started"
+	ConditionHealthy   = "service_healthy"
+	ConditionCompleted = "service_completed_successfully"
+)
+
+// DefaultNetwork is joined by services that don't list any network.
+const DefaultNetwork = "default"
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Stack is a named group of services.
+type Stack struct {
+	Name     string              `yaml:"name"`
+	Services map[string]*Service `yaml:"services"`
+	// Networks and Volumes are declared by their short name and created
+	// as <stack>_<name>.
+	Networks []string `yaml:"networks"`
+	Volumes  []string `yaml:"volumes"`
+
+	// File is the absolute path the stack was loaded from, relative bind
+	// mounts are resolved against its directory.
+	File string `yaml:"-"`
+	// Warnings lists what a Compose file asks for that stackr ignores.
+	Warnings []string `yaml:"-"`
+	// Disabled lists the services of a Compose file left out because none
+	// of their profiles is enabled. Their containers aren't orphans.
+	Disabled []string `yaml:"-"`
+
+	// names overrides <stack>_<name> for the networks and volumes a
+	// Compose file names explicitly, external ones are used as they are
+	// and never created or removed.
+	networkNames map[string]string
+	volumeNames  map[string]string
+	external     map[string]bool // "network:<n>" and "volume:<v>"
+}
+
+// Service is one container of a stack.
+type Service struct {
+	Image         string       `yaml:"image"`
+	ContainerName string       `yaml:"container_name"`
+	Entrypoint    Command      `yaml:"entrypoint"`
+	Command       Command      `yaml:"command"`
+	Environment   Mapping      `yaml:"environment"`
+	Ports         []string     `yaml:"ports"`
+	Volumes       []string     `yaml:"volumes"`
+	Networks      []string     `yaml:"networks"`
+	NetworkMode   string       `yaml:"network_mode"`
+	Aliases       []string     `yaml:"aliases"`
+	DependsOn     Dependencies `yaml:"depends_on"`
+	Restart       string       `yaml:"restart"`
+	Memory        string       `yaml:"memory"`
+	CPUs          string       `yaml:"cpus"`
+	Labels        Mapping      `yaml:"labels"`
+	Healthcheck   *Healthcheck `yaml:"healthcheck"`
+}
+
+// Healthcheck is written like the docker run --health-* flags.
+type Healthcheck struct {
+	Test        HealthTest    `yaml:"test"`
+	Interval    time.Duration `yaml:"interval"`
+	Timeout     time.Duration `yaml:"timeout"`
+	StartPeriod time.Duration `yaml:"start_period"`
+	Retries     int           `yaml:"retries"`
+	Disable     bool          `yaml:"disable"`
+}
+
+// Mapping accepts both the map form (KEY: value) and the list form
+// (- KEY=value).
+type Mapping map[string]string
+
+func (m *Mapping) UnmarshalYAML(n *yaml.Node) error {
+	out := Mapping{}
+	switch n.Kind {
+	case yaml.MappingNode:
+		raw := map[string]*string{}
+		if err := n.Decode(&raw); err != nil {
+			return err
+		}
+		for k, v := range raw {
+			if v != nil {
+				out[k] = *v
+			} else {
+				out[k] = ""
+			}
+		}
+	case yaml.SequenceNode:
+		var list []string
+		if err := n.Decode(&list); err != nil {
+			return err
+		}
+		for _, kv := range list {
+			k, v, _ := strings.Cut(kv, "=")
+			out[k] = v
+		}
+	default:
+		return fmt.Errorf("line %d: expected a map or a list of KEY=value", n.Line)
+	}
+	*m = out
+	return nil
+}
+
+// Command accepts a list or a string, which is split like a shell would.
+type Command []string
+
+func (c *Command) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		words, err := format.SplitWords(n.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		*c = words
+		return nil
+	case yaml.SequenceNode:
+		var list []string
+		if err := n.Decode(&list); err != nil {
+			return err
+		}
+		*c = list
+		return nil
+	}
+	return fmt.Errorf("line %d: expected a string or a list", n.Line)
+}
+
+// HealthTest is the healthcheck command. A string runs in a shell, a list
+// starts with CMD, CMD-SHELL or NONE as in the Docker API.
+type HealthTest []string
+
+func (t *HealthTest) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		*t = HealthTest{"CMD-SHELL", n.Value}
+		return nil
+	case yaml.SequenceNode:
+		var list []string
+		if err := n.Decode(&list); err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return fmt.Errorf("line %d: empty healthcheck test", n.Line)
+		}
+		switch list[0] {
+		case "CMD", "CMD-SHELL", "NONE":
+		default:
+			return fmt.Errorf("line %d: healthcheck test must start with CMD, CMD-SHELL or NONE", n.Line)
+		}
+		*t = list
+		return nil
+	}
+	return fmt.Errorf("line %d: expected a string or a list", n.Line)
+}
+
+// Dependency is an entry of depends_on.
+type Dependency struct {
+	Service   string
+	Condition string
+}
+
+// Dependencies accepts the short list form (- db) and the map form with a
+// condition (db: {condition: service_healthy}).
+type Dependencies []Dependency
+
+func (d *Dependencies) UnmarshalYAML(n *yaml.Node) error {
+	var out Dependencies
+	switch n.Kind {
+	case yaml.SequenceNode:
+		var list []string
+		if err := n.Decode(&list); err != nil {
+			return err
+		}
+		for _, s := range list {
+			out = append(out, Dependency{Service: s, Condition: ConditionStarted})
+		}
+	case yaml.MappingNode:
+		var raw map[string]struct {
+			Condition string `yaml:"condition"`
+		}
+		if err := n.Decode(&raw); err != nil {
+			return err
+		}
+		for s, v := range raw {
+			cond := v.Condition
+			if cond == "" {
+				cond = ConditionStarted
+			}
+			out = append(out, Dependency{Service: s, Condition: cond})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Service < out[j].Service })
+	default:
+		return fmt.Errorf("line %d: expected a list of services or a map", n.Line)
+	}
+	*d = out
+	return nil
+}
+
+// Load reads and validates a stack file. Compose files, recognised by
+// name, are converted with the given profiles enabled on top of the ones
+// in COMPOSE_PROFILES.
+func Load(path string, profiles ...string) (*Stack, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if IsComposeFile(abs) {
+		return LoadCompose(abs, profiles)
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	var st Stack
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&st); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	st.File = abs
+	if err := st.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &st, nil
+}
+
+// Dir is the directory relative paths in the stack are resolved against.
+func (st *Stack) Dir() string {
+	return filepath.Dir(st.File)
+}
+
+func (st *Stack) Validate() error {
+	if !namePattern.MatchString(st.Name) {
+		return fmt.Errorf("stack name %q must be lowercase letters, digits, - and _", st.Name)
+	}
+	if len(st.Services) == 0 {
+		return fmt.Errorf("stack %s has no services", st.Name)
+	}
+	names := map[string]string{}
+	networks := map[string]bool{DefaultNetwork: true}
+	for _, n := range st.Networks {
+		networks[n] = true
+	}
+	volumes := map[string]bool{}
+	for _, v := range st.Volumes {
+		volumes[v] = true
+	}
+
+	for name, svc := range st.Services {
+		if !namePattern.MatchString(name) {
+			return fmt.Errorf("service name %q must be lowercase letters, digits, - and _", name)
+		}
+		if svc == nil || svc.Image == "" {
+			return fmt.Errorf("service %s: image is required", name)
+		}
+		if other, ok := names[st.ContainerName(name)]; ok {
+			return fmt.Errorf("services %s and %s use the same container name %s", other, name, st.ContainerName(name))
+		}
+		names[st.ContainerName(name)] = name
+		if svc.NetworkMode != "" && len(svc.Networks) > 0 {
+			return fmt.Errorf("service %s: network_mode and networks can't be combined", name)
+		}
+		for _, n := range svc.Networks {
+			if !networks[n] {
+				return fmt.Errorf("service %s: network %q is not declared", name, n)
+			}
+		}
+		for _, v := range svc.Volumes {
+			if src, _, _ := strings.Cut(v, ":"); isNamedVolume(src) && !volumes[src] {
+				return fmt.Errorf("service %s: volume %q is not declared", name, src)
+			}
+		}
+		for _, d := range svc.DependsOn {
+			if st.Services[d.Service] == nil {
+				return fmt.Errorf("service %s depends on unknown service %q", name, d.Service)
+			}
+			switch d.Condition {
+			case ConditionStarted, ConditionHealthy, ConditionCompleted:
+			default:
+				return fmt.Errorf("service %s: unknown depends_on condition %q", name, d.Condition)
+			}
+		}
+		if _, err := st.Spec(name); err != nil {
+			return fmt.Errorf("service %s: %w", name, err)
+		}
+	}
+	_, err := st.Order()
+	return err
+}
+
+// Order returns the services so that every service comes after the ones it
+// depends on. Services are otherwise sorted by name.
+func (st *Stack) Order() ([]string, error) {
+	deps := map[string][]string{}
+	for name, svc := range st.Services {
+		for _, d := range svc.DependsOn {
+			deps[name] = append(deps[name], d.Service)
+		}
+	}
+	return Order(deps, st.ServiceNames())
+}
+
+// ServiceNames returns the service names, sorted.
+func (st *Stack) ServiceNames() []string {
+	names := make([]string, 0, len(st.Services))
+	for name := range st.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Order sorts names so that each comes after its deps. Dependencies
+// outside names are ignored.
+func Order(deps map[string][]string, names []string) ([]string, error) {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	known := map[string]bool{}
+	for _, n := range sorted {
+		known[n] = true
+	}
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var order []string
+	var visit func(n string, path []string) error
+	visit = func(n string, path []string) error {
+		switch state[n] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, n), " -> "))
+		}
+		state[n] = visiting
+		ds := append([]string(nil), deps[n]...)
+		sort.Strings(ds)
+		for _, d := range ds {
+			if !known[d] {
+				continue
+			}
+			if err := visit(d, append(path, n)); err != nil {
+				return err
+			}
+		}
+		state[n] = done
+		order = append(order, n)
+		return nil
+	}
+	for _, n := range sorted {
+		if err := visit(n, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// ContainerName is the name stackr gives the container of a service, the
+// same one Compose would use.
+func (st *Stack) ContainerName(service string) string {
+	if svc := st.Services[service]; svc != nil && svc.ContainerName != "" {
+		return svc.ContainerName
+	}
+	return st.Name + "-" + service + "-1"
+}
+
+// NetworkName and VolumeName prefix a declared network or volume with the
+// stack name, unless the stack names it explicitly.
+func (st *Stack) NetworkName(n string) string {
+	if name, ok := st.networkNames[n]; ok {
+		return name
+	}
+	return st.Name + "_" + n
+}
+
+func (st *Stack) VolumeName(v string) string {
+	if name, ok := st.volumeNames[v]; ok {
+		return name
+	}
+	return st.Name + "_" + v
+}
+
+// ExternalNetwork and ExternalVolume tell whether the network or volume is
+// managed outside the stack.
+func (st *Stack) ExternalNetwork(n string) bool { return st.external["network:"+n] }
+func (st *Stack) ExternalVolume(v string) bool  { return st.external["volume:"+v] }
+
+// Spec is the container a service runs as, labels and config hash
+// included.
+func (st *Stack) Spec(service string) (docker.CreateSpec, error) {
+	svc := st.Services[service]
+	spec := docker.CreateSpec{
+		Image:   svc.Image,
+		Name:    st.ContainerName(service),
+		Ports:   svc.Ports,
+		Restart: svc.Restart,
+		Memory:  svc.Memory,
+		CPUs:    svc.CPUs,
+		Command: svc.Command,
+		Aliases: append([]string{service}, svc.Aliases...),
+
+		Entrypoint: svc.Entrypoint,
+	}
+	for _, k := range sortedKeys(svc.Environment) {
+		spec.Env = append(spec.Env, k+"="+svc.Environment[k])
+	}
+	for _, v := range svc.Volumes {
+		spec.Volumes = append(spec.Volumes, st.resolveVolume(v))
+	}
+
+	if svc.NetworkMode != "" {
+		spec.Network, spec.Aliases = svc.NetworkMode, nil
+	} else {
+		networks := svc.Networks
+		if len(networks) == 0 {
+			networks = []string{DefaultNetwork}
+		}
+		spec.Network = st.NetworkName(networks[0])
+		for _, n := range networks[1:] {
+			spec.ExtraNetworks = append(spec.ExtraNetworks, st.NetworkName(n))
+		}
+	}
+
+	if h := svc.Healthcheck; h != nil {
+		spec.Health = &container.HealthConfig{
+			Interval:    h.Interval,
+			Timeout:     h.Timeout,
+			StartPeriod: h.StartPeriod,
+			Retries:     h.Retries,
+		}
+		if h.Disable {
+			spec.Health.Test = []string{"NONE"}
+		} else {
+			spec.Health.Test = h.Test
+		}
+	}
+
+	labels := map[string]string{
+		LabelProject:     st.Name,
+		LabelService:     service,
+		LabelNumber:      "1",
+		LabelOneoff:      "False",
+		LabelConfigFiles: st.File,
+		LabelWorkingDir:  st.Dir(),
+	}
+	var deps []string
+	for _, d := range svc.DependsOn {
+		deps = append(deps, d.Service+":"+d.Condition+":false")
+	}
+	if len(deps) > 0 {
+		labels[LabelDependsOn] = strings.Join(deps, ",")
+	}
+	for k, v := range svc.Labels {
+		labels[k] = v
+	}
+	for _, k := range sortedKeys(labels) {
+		spec.Labels = append(spec.Labels, k+"="+labels[k])
+	}
+
+	if err := spec.Validate(); err != nil {
+		return spec, err
+	}
+
+	// The hash covers everything above, so any edit to the service shows
+	// up as drift, but where the file lives: a checkout moved or reached
+	// through another path is the same stack.
+	hashed := spec
+	hashed.Labels = slices.DeleteFunc(slices.Clone(spec.Labels), func(l string) bool {
+		k, _, _ := strings.Cut(l, "=")
+		return k == LabelConfigFiles || k == LabelWorkingDir
+	})
+	data, err := json.Marshal(hashed)
+	if err != nil {
+		return spec, err
+	}
+	sum := sha256.Sum256(data)
+	spec.Labels = append(spec.Labels, LabelConfigHash+"="+hex.EncodeToString(sum[:]))
+	return spec, nil
+}
+
+// resolveVolume prefixes named volumes with the stack name and makes
+// relative bind mounts absolute.
+func (st *Stack) resolveVolume(v string) string {
+	src, rest, _ := strings.Cut(v, ":")
+	switch {
+	case isNamedVolume(src):
+		src = st.VolumeName(src)
+	case !filepath.IsAbs(src):
+		src = filepath.Join(st.Dir(), src)
+	}
+	return src + ":" + rest
+}
+
+// isNamedVolume tells a volume name from a host path, the same way docker
+// run -v does.
+func isNamedVolume(src string) bool {
+	return src != "" && !strings.ContainsAny(src[:1], "/.~")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FileNames are looked up, in order, when no stack file is given. Compose
+// files come last so a stackr.yaml next to one wins.
+var FileNames = []string{"stackr.yaml", "stackr.yml", "compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// FindFile returns the first of FileNames present in dir.
+func FindFile(dir string) (string, error) {
+	for _, name := range FileNames {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no %s in %s, use -f to point at a stack file", strings.Join(FileNames, " or "), dir)
+}
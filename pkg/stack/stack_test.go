@@ -0,0 +1,33 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpecHashIgnoresLocation(t *testing.T) {
+	hash := func(dir string) string {
+		t.Helper()
+		file := filepath.Join(dir, "compose.yaml")
+		data := []byte("name: shop\nservices:\n  web:\n    image: nginx:1.27\n")
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		st, err := LoadCompose(file, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spec, err := st.Spec("web")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return specHash(spec.Labels)
+	}
+
+	// The same file checked out in two places is the same stack.
+	a, b := hash(t.TempDir()), hash(t.TempDir())
+	if a == "" || a != b {
+		t.Errorf("hashes = %q, %q, want them equal", a, b)
+	}
+}
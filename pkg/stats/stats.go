@@ -0,0 +1,188 @@
+// Package stats turns the daemon's stats readings into the numbers docker
+// stats shows, for Linux containers on cgroup v1 and v2 and for Windows
+// containers.
+package stats
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+// CPUPercent is the container CPU usage between the previous and current
+// sample, scaled to the number of CPUs (200% = two full cores). It is 0
+// when the reading has no previous sample, see Sampler, or when the
+// counters went backwards because the container restarted in between.
+func CPUPercent(s *container.StatsResponse) float64 {
+	if !HasPrevious(s) {
+		return 0
+	}
+	if s.OSType == "windows" {
+		return windowsCPUPercent(s)
+	}
+	cpuDelta, ok := delta(s.CPUStats.CPUUsage.TotalUsage, s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta, ok2 := delta(s.CPUStats.SystemUsage, s.PreCPUStats.SystemUsage)
+	if !ok || !ok2 || systemDelta == 0 {
+		return 0
+	}
+	return float64(cpuDelta) / float64(systemDelta) * float64(OnlineCPUs(s)) * 100
+}
+
+// PerCPUPercent is the usage of each CPU between the previous and current
+// sample, 100% being one full core. It is nil when the daemon doesn't
+// report usage per CPU, as on cgroup v2 and Windows.
+func PerCPUPercent(s *container.StatsResponse) []float64 {
+	cur, pre := s.CPUStats.CPUUsage.PercpuUsage, s.PreCPUStats.CPUUsage.PercpuUsage
+	if !HasPrevious(s) || len(cur) == 0 || len(cur) != len(pre) {
+		return nil
+	}
+	systemDelta, ok := delta(s.CPUStats.SystemUsage, s.PreCPUStats.SystemUsage)
+	if !ok || systemDelta == 0 {
+		return nil
+	}
+	// System usage is summed over every CPU of the host.
+	perCPU := float64(systemDelta) / float64(OnlineCPUs(s))
+	out := make([]float64, len(cur))
+	for i := range cur {
+		if d, ok := delta(cur[i], pre[i]); ok {
+			out[i] = float64(d) / perCPU * 100
+		}
+	}
+	return out
+}
+
+// OnlineCPUs is the number of CPUs the container can use. Older daemons
+// leave online_cpus out; the per CPU counters tell it on cgroup v1, the
+// processor count on Windows. It is at least 1.
+func OnlineCPUs(s *container.StatsResponse) int {
+	switch {
+	case s.CPUStats.OnlineCPUs > 0:
+		return int(s.CPUStats.OnlineCPUs)
+	case len(s.CPUStats.CPUUsage.PercpuUsage) > 0:
+		return len(s.CPUStats.CPUUsage.PercpuUsage)
+	case s.NumProcs > 0:
+		return int(s.NumProcs)
+	}
+	return 1
+}
+
+// windowsCPUPercent compares the CPU time used, in 100ns units, with the
+// time that passed on every processor.
+func windowsCPUPercent(s *container.StatsResponse) float64 {
+	elapsed := s.Read.Sub(s.PreRead)
+	if elapsed <= 0 {
+		return 0
+	}
+	possible := float64(elapsed.Nanoseconds()) / 100 * float64(OnlineCPUs(s))
+	used, ok := delta(s.CPUStats.CPUUsage.TotalUsage, s.PreCPUStats.CPUUsage.TotalUsage)
+	if !ok {
+		return 0
+	}
+	return float64(used) / possible * 100
+}
+
+// MemoryUsage is the memory the container uses the way docker stats counts
+// it: without the page cache the kernel can reclaim (inactive_file, or
+// total_inactive_file on cgroup v1), or the private working set on
+// Windows.
+func MemoryUsage(s *container.StatsResponse) uint64 {
+	m := s.MemoryStats
+	if s.OSType == "windows" {
+		return m.PrivateWorkingSet
+	}
+	if v, ok := m.Stats["total_inactive_file"]; ok && v < m.Usage {
+		return m.Usage - v
+	}
+	if v := m.Stats["inactive_file"]; v < m.Usage {
+		return m.Usage - v
+	}
+	return m.Usage
+}
+
+// MemoryPercent is MemoryUsage against the limit, 0 when there is none
+// (Windows doesn't report one).
+func MemoryPercent(s *container.StatsResponse) float64 {
+	if s.MemoryStats.Limit == 0 {
+		return 0
+	}
+	return float64(MemoryUsage(s)) / float64(s.MemoryStats.Limit) * 100
+}
+
+// NetworkIO sums received and transmitted bytes over all interfaces.
+func NetworkIO(stats *container.StatsResponse) (rx, tx uint64) {
+	for _, n := range stats.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	return rx, tx
+}
+
+// BlockIO sums bytes read and written over all block devices.
+func BlockIO(stats *container.StatsResponse) (read, write uint64) {
+	for _, e := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			read += e.Value
+		case "write":
+			write += e.Value
+		}
+	}
+	return read, write
+}
+
+// HasPrevious reports whether the reading carries the previous sample CPU
+// usage is computed against. One-shot readings and the first reading of a
+// stream don't.
+func HasPrevious(s *container.StatsResponse) bool {
+	return !s.PreRead.IsZero() || s.PreCPUStats.CPUUsage.TotalUsage != 0 || s.PreCPUStats.SystemUsage != 0
+}
+
+// delta is cur - pre, not ok when the counter went backwards.
+func delta(cur, pre uint64) (uint64, bool) {
+	if cur < pre {
+		return 0, false
+	}
+	return cur - pre, true
+}
+
+// samplerTTL is how long a Sampler remembers a container it no longer
+// hears about.
+const samplerTTL = 10 * time.Minute
+
+// Sampler remembers the last reading of each container so readings taken
+// without a previous sample, such as one-shot ones taken at an interval,
+// can be primed with it. It is safe for concurrent use.
+type Sampler struct {
+	mu   sync.Mutex
+	last map[string]sample
+}
+
+type sample struct {
+	read time.Time
+	cpu  container.CPUStats
+}
+
+func NewSampler() *Sampler {
+	return &Sampler{last: map[string]sample{}}
+}
+
+// Prime fills in the previous sample of s from the last reading of the
+// container id, unless s has one, and remembers s for the next call. The
+// first reading of a container stays without, its CPU usage reads 0.
+func (p *Sampler) Prime(id string, s *container.StatsResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if last, ok := p.last[id]; ok && !HasPrevious(s) && last.read.Before(s.Read) {
+		s.PreRead = last.read
+		s.PreCPUStats = last.cpu
+	}
+	p.last[id] = sample{read: s.Read, cpu: s.CPUStats}
+
+	for id, last := range p.last {
+		if s.Read.Sub(last.read) > samplerTTL {
+			delete(p.last, id)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+// load reads a stats reading as the daemon sends it from testdata.
+func load(t *testing.T, name string) *container.StatsResponse {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s container.StatsResponse
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return &s
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestFixtures(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		cpu        float64
+		perCPU     []float64
+		onlineCPUs int
+		mem        uint64
+		memPercent float64
+	}{
+		// Cache is left out through total_inactive_file.
+		{"cgroup_v1.json", 100, []float64{50, 50}, 2, 419430400, 40},
+		// No per CPU counters on cgroup v2, cache through inactive_file.
+		{"cgroup_v2.json", 50, nil, 4, 262144000, 12.5},
+		// online_cpus missing, the per CPU counters tell there are 4.
+		{"no_online_cpus.json", 200, []float64{50, 50, 50, 50}, 4, 104857600, 0},
+		// 100ns intervals over one second on num_procs processors, memory
+		// is the private working set and there is no limit.
+		{"windows.json", 50, nil, 4, 73400320, 0},
+		// Counters went backwards: the container restarted in between.
+		{"restarted.json", 0, []float64{0, 0}, 2, 10485760, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			s := load(t, tt.fixture)
+			if got := CPUPercent(s); !near(got, tt.cpu) {
+				t.Errorf("CPUPercent = %v, want %v", got, tt.cpu)
+			}
+			got := PerCPUPercent(s)
+			if len(got) != len(tt.perCPU) || (got == nil) != (tt.perCPU == nil) {
+				t.Fatalf("PerCPUPercent = %v, want %v", got, tt.perCPU)
+			}
+			for i := range got {
+				if !near(got[i], tt.perCPU[i]) {
+					t.Errorf("PerCPUPercent = %v, want %v", got, tt.perCPU)
+					break
+				}
+			}
+			if got := OnlineCPUs(s); got != tt.onlineCPUs {
+				t.Errorf("OnlineCPUs = %d, want %d", got, tt.onlineCPUs)
+			}
+			if got := MemoryUsage(s); got != tt.mem {
+				t.Errorf("MemoryUsage = %d, want %d", got, tt.mem)
+			}
+			if got := MemoryPercent(s); !near(got, tt.memPercent) {
+				t.Errorf("MemoryPercent = %v, want %v", got, tt.memPercent)
+			}
+		})
+	}
+}
+
+func TestSamplerPrime(t *testing.T) {
+	p := NewSampler()
+
+	first := load(t, "oneshot_first.json")
+	if HasPrevious(first) {
+		t.Fatal("one-shot reading has a previous sample")
+	}
+	p.Prime(first.ID, first)
+	if HasPrevious(first) {
+		t.Error("first reading was primed with nothing to prime it with")
+	}
+	if got := CPUPercent(first); got != 0 {
+		t.Errorf("CPUPercent of the first reading = %v, want 0", got)
+	}
+
+	second := load(t, "oneshot_second.json")
+	p.Prime(second.ID, second)
+	if !HasPrevious(second) {
+		t.Fatal("second reading was not primed")
+	}
+	if !second.PreRead.Equal(first.Read) {
+		t.Errorf("PreRead = %v, want the first reading's %v", second.PreRead, first.Read)
+	}
+	// 1s of CPU over 4s of system time on 2 CPUs.
+	if got := CPUPercent(second); !near(got, 50) {
+		t.Errorf("CPUPercent of the second reading = %v, want 50", got)
+	}
+
+	// A reading from the daemon with its own previous sample is kept as is.
+	own := load(t, "cgroup_v2.json")
+	p.Prime(second.ID, own)
+	if !near(CPUPercent(own), 50) || own.PreCPUStats.CPUUsage.TotalUsage != 1000000000 {
+		t.Error("reading with a previous sample was primed over")
+	}
+
+	// Another container is not primed with this one's readings.
+	other := load(t, "oneshot_first.json")
+	p.Prime("other", other)
+	if HasPrevious(other) {
+		t.Error("reading primed with another container's")
+	}
+}
@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"github.com/aogirikarma/mini-stackr-cli/pkg/alert"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type alertMsg alert.Alert
+
+// waitAlert blocks for the next alert from the watcher. It returns nil once
+// the watcher is gone, or right away when alerting is off.
+func (m model) waitAlert() tea.Cmd {
+	ch := m.alerts
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		a, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return alertMsg(a)
+	}
+}
+
+func (m model) renderAlertBar() string {
+	if m.alert == nil {
+		return ""
+	}
+	return warningStyle.Render("\n  ⚠ " + m.alert.Time.Local().Format("15:04:05") + " " + m.alert.String())
+}
@@ -3,16 +3,45 @@ package tui
 import (
 	"context"
 
+	"github.com/aogirikarma/mini-stackr-cli/pkg/alert"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/config"
 	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/history"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func Run(client *docker.Client) error {
+// Run starts the TUI. Alert rules are watched and stats history recorded
+// for as long as it runs.
+func Run(client *docker.Client, cfg config.Config) error {
 	m := newModel(client)
-	p := tea.NewProgram(m, tea.WithAltScreen())
-	_, err := p.Run()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if len(cfg.Alerts.Rules) > 0 {
+		w := alert.NewWatcher(client, cfg.Alerts)
+		go w.Run(ctx)
+		m.alerts = w.Alerts()
+	}
+	if !cfg.History.Disabled {
+		store, err := config.HistoryStore(cfg)
+		if err != nil {
+			return err
+		}
+		go history.NewRecorder(client, store).Run(ctx)
+		m.history = store
+	}
+
+	notes, err := config.NotesStore()
+	if err != nil {
+		return err
+	}
+	m.notes = notes
+
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithOutput(stdout))
+	_, err = p.Run()
 	return err
 }
 
@@ -20,21 +49,26 @@ func newModel(client *docker.Client) model {
 	vp := viewport.New(80, 20)
 	vp.SetContent("")
 
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "name, image, project, note or #tag"
+
 	return model{
 		client:   client,
 		view:     viewList,
 		viewport: vp,
+		search:   search,
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return m.fetchContainers
+	return tea.Batch(m.fetchContainers, m.waitAlert())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if key.Matches(msg, keys.Quit) {
+		if key.Matches(msg, keys.Quit) && (!m.typing() || msg.Type == tea.KeyCtrlC) {
 			return m, tea.Quit
 		}
 
@@ -46,12 +80,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.view == viewDetail && m.inspect != nil {
 			m.viewport.SetContent(m.renderDetailContent())
 		}
+		if m.view == viewExport {
+			m.viewport.SetContent(m.renderExportContent())
+		}
+		if m.view == viewCharts {
+			m.viewport.SetContent(m.renderChartsContent())
+		}
 		return m, nil
 
 	case containersMsg:
 		m.containers = msg
+		// Outside the list the selection is the container on screen, it must
+		// not move when that one drops out of the list.
+		if m.view == viewList {
+			m.restoreSelection()
+		}
+		return m, m.refreshDrift()
+
+	case driftMsg:
+		m.drift = msg
 		return m, nil
 
+	case topMsg, topTickMsg:
+		return m.updateTop(msg)
+
+	case imagesMsg, imageUpdatedMsg:
+		return m.updateImages(msg)
+
+	case stackStepMsg, stackDoneMsg:
+		return m.updateStackAction(msg)
+
+	case alertMsg:
+		a := alert.Alert(msg)
+		m.alert = &a
+		return m, tea.Batch(m.waitAlert(), m.fetchContainers)
+
 	case errMsg:
 		m.err = msg
 		return m, nil
@@ -62,6 +125,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateList(msg)
 	case viewDetail:
 		return m.updateDetail(msg)
+	case viewCreate:
+		return m.updateCreate(msg)
+	case viewExport:
+		return m.updateExport(msg)
+	case viewRecreate:
+		return m.updateRecreate(msg)
+	case viewUpdate:
+		return m.updateUpdate(msg)
+	case viewSignal:
+		return m.updateSignal(msg)
+	case viewFiles:
+		return m.updateFiles(msg)
+	case viewUpload:
+		return m.updateUpload(msg)
+	case viewCharts:
+		return m.updateCharts(msg)
+	case viewSystem:
+		return m.updateSystem(msg)
+	case viewPrune:
+		return m.updatePrune(msg)
+	case viewRename:
+		return m.updateRename(msg)
+	case viewNotes:
+		return m.updateNotes(msg)
+	case viewPorts:
+		return m.updatePorts(msg)
+	case viewYank:
+		return m.updateYank(msg)
+	case viewStats:
+		return m.updateStatsTable(msg)
 	}
 
 	return m, nil
@@ -77,11 +170,53 @@ func (m model) View() string {
 		return m.viewList()
 	case viewDetail:
 		return m.viewDetail()
+	case viewCreate:
+		return m.viewCreate()
+	case viewExport:
+		return m.viewExport()
+	case viewRecreate:
+		return m.viewRecreate()
+	case viewUpdate:
+		return m.viewUpdate()
+	case viewSignal:
+		return m.viewSignal()
+	case viewFiles:
+		return m.viewFiles()
+	case viewUpload:
+		return m.viewUpload()
+	case viewCharts:
+		return m.viewCharts()
+	case viewSystem:
+		return m.viewSystem()
+	case viewPrune:
+		return m.viewPrune()
+	case viewRename:
+		return m.viewRename()
+	case viewNotes:
+		return m.viewNotes()
+	case viewPorts:
+		return m.viewPorts()
+	case viewYank:
+		return m.viewYank()
+	case viewStats:
+		return m.viewStatsTable()
 	}
 
 	return ""
 }
 
+// typing reports whether the current view has a focused text input, in
+// which case plain letters must reach the input instead of the key map.
+func (m model) typing() bool {
+	switch m.view {
+	case viewCreate, viewRecreate, viewUpdate, viewSignal, viewUpload, viewRename, viewNotes:
+		return true
+	case viewList:
+		return m.searching
+	}
+	return false
+}
+
 func (m model) fetchContainers() tea.Msg {
 	containers, err := m.client.ListContainers(context.Background())
 	if err != nil {
@@ -0,0 +1,236 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/history"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chartRanges are the time spans the charts view cycles through.
+var chartRanges = []struct {
+	label string
+	span  time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+const (
+	chartHeight = 5
+	// chartLabelWidth is the room left of the plot for the axis labels.
+	chartLabelWidth = 10
+)
+
+// chartLevels are the partial blocks drawn at the top of a column, in
+// eighths of a cell.
+var chartLevels = []rune(" ▁▂▃▄▅▆▇█")
+
+type charts struct {
+	rangeIdx int
+	name     string
+	samples  []history.Sample
+	loadedAt time.Time
+}
+
+type historyMsg struct {
+	name    string
+	samples []history.Sample
+	at      time.Time
+}
+type chartsStatusMsg string
+
+func (m model) enterCharts() (model, tea.Cmd) {
+	m.view = viewCharts
+	m.status = ""
+	m.charts = charts{name: strings.TrimPrefix(m.inspect.Name, "/")}
+	m.viewport.SetContent(m.renderChartsContent())
+	m.viewport.GotoTop()
+	return m, m.loadHistory()
+}
+
+func (m model) updateCharts(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			m.status = ""
+			m.viewport.SetContent(m.renderDetailContent())
+			return m, nil
+		case key.Matches(msg, keys.Toggle):
+			m.charts.rangeIdx = (m.charts.rangeIdx + 1) % len(chartRanges)
+			return m, m.loadHistory()
+		case key.Matches(msg, keys.Refresh):
+			return m, m.loadHistory()
+		}
+
+	case historyMsg:
+		if msg.name != m.charts.name {
+			return m, nil
+		}
+		m.charts.samples = msg.samples
+		m.charts.loadedAt = msg.at
+		m.status = ""
+		m.viewport.SetContent(m.renderChartsContent())
+		return m, nil
+
+	case chartsStatusMsg:
+		m.status = string(msg)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m model) viewCharts() string {
+	var b strings.Builder
+	b.WriteString(m.viewport.View())
+	b.WriteString("\n")
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+		b.WriteString("\n")
+	}
+	help := "[↑↓] scroll  [tab] range  [f]refresh  [esc]back  [q]uit"
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
+
+func (m model) loadHistory() tea.Cmd {
+	name := m.charts.name
+	span := chartRanges[m.charts.rangeIdx].span
+	store := m.history
+	return func() tea.Msg {
+		if store == nil {
+			return chartsStatusMsg("History is disabled in the config file.")
+		}
+		now := time.Now()
+		samples, err := store.Load(name, now.Add(-span))
+		if err != nil {
+			return chartsStatusMsg("Error: " + err.Error())
+		}
+		return historyMsg{name: name, samples: samples, at: now}
+	}
+}
+
+func (m model) renderChartsContent() string {
+	c := m.charts
+	var b strings.Builder
+
+	var ranges []string
+	for i, r := range chartRanges {
+		if i == c.rangeIdx {
+			ranges = append(ranges, selectedStyle.Render("["+r.label+"]"))
+		} else {
+			ranges = append(ranges, statusStyle.Render(" "+r.label+" "))
+		}
+	}
+	b.WriteString(titleStyle.Render("⬡ HISTORY "+c.name) + "  " + strings.Join(ranges, " ") + "\n\n")
+
+	if c.loadedAt.IsZero() {
+		b.WriteString(statusStyle.Render("  Loading...\n"))
+		return b.String()
+	}
+	if len(c.samples) == 0 {
+		b.WriteString(statusStyle.Render("  No samples recorded yet. Stats are recorded every minute while\n  stackr or stackr watch is running.\n"))
+		return b.String()
+	}
+
+	span := chartRanges[c.rangeIdx].span
+	from := c.loadedAt.Add(-span)
+	cols := max(m.width-chartLabelWidth-4, 10)
+	// Never use more buckets than there can be samples, stretching them
+	// over the width instead of leaving every other column empty.
+	buckets := min(cols, int(span/history.Interval))
+	points := history.Bucket(c.samples, from, c.loadedAt, buckets)
+
+	series := func(f func(history.Point) float64) []float64 {
+		values := make([]float64, len(points))
+		for i, p := range points {
+			values[i] = f(p)
+		}
+		return values
+	}
+	valid := make([]bool, len(points))
+	for i, p := range points {
+		valid[i] = p.Valid
+	}
+	percent := func(v float64) string { return fmt.Sprintf("%.0f%%", v) }
+	bytes := func(v float64) string { return format.Bytes(uint64(v)) }
+	rate := func(v float64) string { return format.Bytes(uint64(v)) + "/s" }
+
+	last := c.samples[len(c.samples)-1]
+	memTitle := "MEMORY"
+	if last.MemLimit > 0 {
+		memTitle += "  limit " + format.Bytes(last.MemLimit)
+	}
+
+	b.WriteString(renderChart("CPU", series(func(p history.Point) float64 { return p.CPU }), valid, cols, percent))
+	b.WriteString(renderChart(memTitle, series(func(p history.Point) float64 { return p.Mem }), valid, cols, bytes))
+	b.WriteString(renderChart("NET RX", series(func(p history.Point) float64 { return p.NetRx }), valid, cols, rate))
+	b.WriteString(renderChart("NET TX", series(func(p history.Point) float64 { return p.NetTx }), valid, cols, rate))
+	b.WriteString(renderChart("BLOCK READ", series(func(p history.Point) float64 { return p.BlockRead }), valid, cols, rate))
+	b.WriteString(renderChart("BLOCK WRITE", series(func(p history.Point) float64 { return p.BlockWrite }), valid, cols, rate))
+
+	axis := fmt.Sprintf("%-*s%s", chartLabelWidth+2, "", from.Local().Format("01-02 15:04"))
+	now := "now"
+	pad := max(chartLabelWidth+2+cols-len(axis)-len(now), 1)
+	b.WriteString(statusStyle.Render(axis + strings.Repeat(" ", pad) + now))
+	b.WriteString(statusStyle.Render(fmt.Sprintf("\n\n  %d samples", len(c.samples))))
+
+	return b.String()
+}
+
+// renderChart draws values as a bar chart of chartHeight rows, stretched to
+// width columns. Columns without data stay blank.
+func renderChart(title string, values []float64, valid []bool, width int, label func(float64) string) string {
+	peak := 0.0
+	for i, v := range values {
+		if valid[i] {
+			peak = max(peak, v)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(boxTitleStyle.Render(title))
+	b.WriteString(statusStyle.Render("  peak " + label(peak)))
+	b.WriteString("\n")
+
+	for row := chartHeight - 1; row >= 0; row-- {
+		axis := ""
+		switch row {
+		case chartHeight - 1:
+			axis = label(peak)
+		case 0:
+			axis = label(0)
+		}
+		b.WriteString(labelStyle.Render(fmt.Sprintf("%*s ┤", chartLabelWidth, axis)))
+
+		var line strings.Builder
+		for col := 0; col < width; col++ {
+			i := col * len(values) / width
+			if !valid[i] || peak == 0 {
+				line.WriteRune(' ')
+				continue
+			}
+			eighths := int(values[i] / peak * chartHeight * 8)
+			fill := min(max(eighths-row*8, 0), 8)
+			if row == 0 && fill == 0 && values[i] > 0 {
+				// Keep tiny non-zero values visible on the baseline.
+				fill = 1
+			}
+			line.WriteRune(chartLevels[fill])
+		}
+		b.WriteString(runningStyle.Render(line.String()))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
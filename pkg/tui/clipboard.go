@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copiedMsg and openedMsg report a clipboard copy or a browser opened on
+// behalf of the user. what is shown in the status line.
+type copiedMsg struct {
+	what string
+	err  error
+}
+type openedMsg struct {
+	url string
+	err error
+}
+
+// terminal is the program's output. Writes are serialized, so the clipboard
+// sequence copyText sends from its own goroutine lands between two frames
+// of the renderer instead of in the middle of one. It keeps the file's
+// descriptor, which bubbletea needs to size and set up the terminal.
+type terminal struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+var stdout = &terminal{f: os.Stdout}
+
+func (t *terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.f.Write(p)
+}
+
+func (t *terminal) Read(p []byte) (int, error) { return t.f.Read(p) }
+func (t *terminal) Close() error               { return t.f.Close() }
+func (t *terminal) Fd() uintptr                { return t.f.Fd() }
+
+// copyText puts s on the clipboard with an OSC 52 escape sequence, which
+// the terminal turns into a copy on the machine it runs on, so it works
+// over SSH as well. Terminals without OSC 52 support ignore it.
+func copyText(what, s string) tea.Cmd {
+	return func() tea.Msg {
+		seq := osc52.New(s)
+		switch {
+		case os.Getenv("TMUX") != "":
+			seq = seq.Tmux()
+		case os.Getenv("STY") != "":
+			seq = seq.Screen()
+		}
+		_, err := seq.WriteTo(stdout)
+		return copiedMsg{what: what, err: err}
+	}
+}
+
+// openURL hands url to the system's opener, which usually starts a browser.
+func openURL(url string) tea.Cmd {
+	return func() tea.Msg {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", url)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+		default:
+			cmd = exec.Command("xdg-open", url)
+		}
+		if err := cmd.Start(); err != nil {
+			return openedMsg{url: url, err: fmt.Errorf("opening %s: %w", url, err)}
+		}
+		go cmd.Wait()
+		return openedMsg{url: url}
+	}
+}
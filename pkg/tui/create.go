@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	createImage = iota
+	createName
+	createPorts
+	createEnv
+	createVolumes
+	createNetwork
+	createRestart
+	createMemory
+	createCPUs
+	createCommand
+)
+
+type createdMsg struct {
+	id string
+}
+
+func newCreateForm() form {
+	f := newForm("⬡ NEW CONTAINER",
+		"Image", "Name", "Ports", "Env", "Volumes", "Network", "Restart", "Memory", "CPUs", "Command")
+	f.setPlaceholder(createImage, "nginx:alpine")
+	f.setPlaceholder(createName, "optional")
+	f.setPlaceholder(createPorts, "8080:80, 443")
+	f.multiline(createEnv, 3)
+	f.setPlaceholder(createEnv, "KEY=value, one per line")
+	f.setPlaceholder(createVolumes, "/host/path:/data:ro, myvol:/var/lib")
+	f.setPlaceholder(createNetwork, "bridge")
+	f.setPlaceholder(createRestart, "no | always | unless-stopped | on-failure:3")
+	f.setPlaceholder(createMemory, "512m")
+	f.setPlaceholder(createCPUs, "1.5")
+	f.setPlaceholder(createCommand, "image default")
+	return f
+}
+
+// createSpec reads the form. The command is split like a shell would; the
+// spec is returned with the error, so the preview still renders while a
+// quote is being typed.
+func (m model) createSpec() (docker.CreateSpec, error) {
+	command, err := format.SplitWords(m.form.value(createCommand))
+	return docker.CreateSpec{
+		Image:   m.form.value(createImage),
+		Name:    m.form.value(createName),
+		Ports:   m.form.list(createPorts),
+		Env:     m.form.lines(createEnv),
+		Volumes: m.form.list(createVolumes),
+		Network: m.form.value(createNetwork),
+		Restart: m.form.value(createRestart),
+		Memory:  m.form.value(createMemory),
+		CPUs:    m.form.value(createCPUs),
+		Command: command,
+	}, err
+}
+
+func (m model) updateCreate(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewList
+			return m, nil
+		case key.Matches(msg, keys.Submit):
+			spec, err := m.createSpec()
+			if err == nil {
+				err = spec.Validate()
+			}
+			if err != nil {
+				m.form.err = err
+				return m, nil
+			}
+			m.form.err = nil
+			m.status = "Creating " + spec.Image + "..."
+			return m, m.createContainer(spec)
+		}
+	case createdMsg:
+		m.view = viewList
+		m.selectedID = msg.id
+		m.status = ""
+		return m, m.fetchContainers
+	case formErrMsg:
+		m.form.err = msg
+		m.status = ""
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.form, cmd = m.form.update(msg)
+	return m, cmd
+}
+
+func (m model) viewCreate() string {
+	var b strings.Builder
+	spec, _ := m.createSpec()
+
+	b.WriteString(m.form.view(m.width))
+
+	b.WriteString("\n")
+	b.WriteString(boxStyle.Width(max(m.width-2, 40)).Render(
+		boxTitleStyle.Render("DOCKER RUN") + "\n\n" + valueStyle.Render(spec.RunCommand()),
+	))
+	b.WriteString("\n")
+
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+		b.WriteString("\n")
+	}
+
+	help := "[tab/↑↓] field  [ctrl+s] create & start  [esc] cancel  [ctrl+c] quit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) createContainer(spec docker.CreateSpec) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		id, err := m.client.Create(ctx, spec)
+		if err != nil {
+			return formErrMsg(err)
+		}
+		if err := m.client.Start(ctx, id); err != nil {
+			// Don't leave a container behind that the form will create again.
+			if rerr := m.client.Remove(ctx, id); rerr != nil {
+				return formErrMsg(fmt.Errorf("%w; the created container %.12s could not be removed: %v", err, id, rerr))
+			}
+			return formErrMsg(fmt.Errorf("%w (the created container was removed)", err))
+		}
+		return createdMsg{id: id}
+	}
+}
@@ -6,7 +6,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/stats"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	//"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -21,22 +25,97 @@ func (m model) updateDetail(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.view = viewList
 			m.inspect = nil
 			m.stats = nil
+			m.top = nil
+			m.status = ""
+			m.restoreSelection()
 			return m, nil
 		case key.Matches(msg, keys.Stop):
-			return m, tea.Sequence(m.stopContainer, m.fetchContainerDetail)
+			return m, tea.Sequence(m.stopContainer(m.detailID()), m.fetchContainerDetail)
 		case key.Matches(msg, keys.Start):
-			return m, tea.Sequence(m.startContainer, m.fetchContainerDetail)
+			return m, tea.Sequence(m.startContainer(m.detailID()), m.fetchContainerDetail)
 		case key.Matches(msg, keys.Restart):
-			return m, tea.Sequence(m.restartContainer, m.fetchContainerDetail)
+			return m, tea.Sequence(m.restartContainer(m.detailID()), m.fetchContainerDetail)
 		case key.Matches(msg, keys.Delete):
+			id := m.detailID()
 			m.view = viewList
-			return m, m.deleteContainer
+			m.inspect = nil
+			m.restoreSelection()
+			return m, m.deleteContainer(id)
 		case key.Matches(msg, keys.Refresh):
 			return m, m.fetchContainerDetail
+		case key.Matches(msg, keys.Export):
+			if m.inspect != nil {
+				m.view = viewExport
+				m.exportOverwrite = false
+				m.viewport.SetContent(m.renderExportContent())
+				m.viewport.GotoTop()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Recreate):
+			if m.inspect != nil {
+				m.view = viewRecreate
+				m.form = newRecreateForm(docker.SpecFromInspect(*m.inspect, m.imageConfig))
+				return m, textinput.Blink
+			}
+			return m, nil
+		case key.Matches(msg, keys.Files):
+			if m.inspect != nil {
+				return m.enterFiles()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Charts):
+			if m.inspect != nil {
+				return m.enterCharts()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Signal):
+			if m.inspect != nil {
+				m.view = viewSignal
+				m.status = ""
+				m.form = newSignalForm(strings.TrimPrefix(m.inspect.Name, "/"))
+				return m, textinput.Blink
+			}
+			return m, nil
+		case key.Matches(msg, keys.Yank):
+			return m.enterYank()
+		case key.Matches(msg, keys.Ports):
+			if m.inspect != nil {
+				return m.enterPorts()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Rename):
+			if m.inspect != nil {
+				m.view = viewRename
+				m.status = ""
+				m.form = newRenameForm(strings.TrimPrefix(m.inspect.Name, "/"))
+				return m, textinput.Blink
+			}
+			return m, nil
+		case key.Matches(msg, keys.Notes):
+			if m.inspect != nil {
+				m.view = viewNotes
+				m.status = ""
+				m.form = newNotesForm(strings.TrimPrefix(m.inspect.Name, "/"), m.notes.Get(m.inspect.ID))
+				return m, textinput.Blink
+			}
+			return m, nil
+		case key.Matches(msg, keys.Limits):
+			if m.inspect != nil {
+				m.view = viewUpdate
+				m.status = ""
+				m.form = newUpdateForm(strings.TrimPrefix(m.inspect.Name, "/"), docker.UpdateSpecFromHostConfig(m.inspect.HostConfig))
+				return m, textinput.Blink
+			}
+			return m, nil
 		}
 	case inspectMsg:
+		// A reply for the container shown before a recreate is stale.
+		if msg.inspect.ID != m.selectedID {
+			return m, nil
+		}
 		m.inspect = msg.inspect
 		m.stats = msg.stats
+		m.imageConfig = msg.image
 		// Update viewport content
 		content := m.renderDetailContent()
 		m.viewport.SetContent(content)
@@ -53,20 +132,31 @@ func (m model) updateDetail(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// detailID is the container the detail view and its sub-views act on: the
+// inspected one, or the one being opened while it is still loading.
+func (m model) detailID() string {
+	if m.inspect != nil {
+		return m.inspect.ID
+	}
+	return m.selectedID
+}
+
 func (m model) fetchContainerDetail() tea.Msg {
-	if len(m.containers) == 0 || m.cursor >= len(m.containers) {
+	if m.selectedID == "" {
 		return nil
 	}
-	id := m.containers[m.cursor].ID
+	id := m.selectedID
 
 	inspect, err := m.client.Inspect(context.Background(), id)
 	if err != nil {
 		return errMsg(err)
 	}
 
-	stats, _ := m.client.Stats(context.Background(), id)
+	stats, _ := m.client.StatsWithPrevious(context.Background(), id)
+	// Without the image, recreate and export keep what it set.
+	image, _ := m.client.ImageConfig(context.Background(), inspect)
 
-	return inspectMsg{inspect: &inspect, stats: stats}
+	return inspectMsg{inspect: &inspect, stats: stats, image: image}
 }
 
 func (m model) viewDetail() string {
@@ -84,8 +174,16 @@ func (m model) viewDetail() string {
 	scrollPercent := int(m.viewport.ScrollPercent() * 100)
 	scrollInfo := statusStyle.Render(fmt.Sprintf("  [%d%%]", scrollPercent))
 
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+		b.WriteString("\n")
+	}
+	if bar := m.renderAlertBar(); bar != "" {
+		b.WriteString(bar + "\n")
+	}
+
 	// Help
-	help := "[↑↓] scroll  [s]top  [r]esume  [R]estart  [d]elete  [f]refresh  [esc]back  [q]uit"
+	help := "[↑↓] scroll  [s]top  [r]esume  [R]estart  [d]elete  [e]xport  [E]dit  [u]limits  [K]signal  [o] ports  [y]ank  [m] rename  [N]otes  [F]iles  [c]harts  [f]refresh  [esc]back  [q]uit"
 	b.WriteString(helpStyle.Render(help) + scrollInfo)
 
 	return b.String()
@@ -138,9 +236,23 @@ func (m model) renderDetailContent() string {
 		b.WriteString("\n\n")
 		b.WriteString(m.renderNetworkBox(fullWidth))
 		b.WriteString("\n\n")
+
+		if m.top != nil && len(m.top.Processes) > 0 {
+			b.WriteString(m.renderProcessesBox(fullWidth))
+			b.WriteString("\n\n")
+		}
+		if !m.notes.Get(ins.ID).Empty() {
+			b.WriteString(m.renderNotesBox(fullWidth))
+			b.WriteString("\n\n")
+		}
 		b.WriteString(m.renderInfoBox(fullWidth))
 		b.WriteString("\n\n")
 
+		if ins.State != nil && ins.State.Health != nil {
+			b.WriteString(m.renderHealthBox(fullWidth))
+			b.WriteString("\n\n")
+		}
+
 		if ins.Mounts != nil && len(ins.Mounts) > 0 {
 			b.WriteString(m.renderMountsBox(fullWidth))
 			b.WriteString("\n\n")
@@ -162,9 +274,23 @@ func (m model) renderDetailContent() string {
 		b.WriteString("\n\n")
 
 		fullWidth := availableWidth
+
+		if m.top != nil && len(m.top.Processes) > 0 {
+			b.WriteString(m.renderProcessesBox(fullWidth))
+			b.WriteString("\n\n")
+		}
+		if !m.notes.Get(ins.ID).Empty() {
+			b.WriteString(m.renderNotesBox(fullWidth))
+			b.WriteString("\n\n")
+		}
 		b.WriteString(m.renderInfoBox(fullWidth))
 		b.WriteString("\n\n")
 
+		if ins.State != nil && ins.State.Health != nil {
+			b.WriteString(m.renderHealthBox(fullWidth))
+			b.WriteString("\n\n")
+		}
+
 		if ins.Mounts != nil && len(ins.Mounts) > 0 {
 			b.WriteString(m.renderMountsBox(fullWidth))
 			b.WriteString("\n\n")
@@ -184,6 +310,9 @@ func (m model) renderDetailContent() string {
 	return b.String()
 }
 
+// nearCapPercent is the memory usage above which the limit is highlighted.
+const nearCapPercent = 90
+
 func (m model) renderResourcesBox(width int) string {
 	var content strings.Builder
 	content.WriteString(boxTitleStyle.Render("RESOURCES"))
@@ -195,12 +324,10 @@ func (m model) renderResourcesBox(width int) string {
 	memPercent := 0.0
 
 	if m.stats != nil {
-		cpuPercent = calculateCPUPercent(m.stats)
-		memUsage = m.stats.MemoryStats.Usage
+		cpuPercent = stats.CPUPercent(m.stats)
+		memUsage = stats.MemoryUsage(m.stats)
 		memLimit = m.stats.MemoryStats.Limit
-		if memLimit > 0 {
-			memPercent = float64(memUsage) / float64(memLimit) * 100
-		}
+		memPercent = stats.MemoryPercent(m.stats)
 	}
 
 	barWidth := width - 20
@@ -210,11 +337,24 @@ func (m model) renderResourcesBox(width int) string {
 
 	cpuBar := renderProgressBar(cpuPercent, barWidth)
 	content.WriteString(fmt.Sprintf("%s  %s  %5.1f%%\n", labelStyle.Render("CPU"), cpuBar, cpuPercent))
+	if m.stats != nil {
+		if perCPU := stats.PerCPUPercent(m.stats); len(perCPU) > 1 {
+			cores := make([]string, len(perCPU))
+			for i, p := range perCPU {
+				cores[i] = fmt.Sprintf("%.0f%%", p)
+			}
+			content.WriteString(fmt.Sprintf("%s  %s\n", labelStyle.Render("Cores"), valueStyle.Render(format.Truncate(strings.Join(cores, " "), width-12))))
+		}
+	}
 
 	memBar := renderProgressBar(memPercent, barWidth)
-	content.WriteString(fmt.Sprintf("%s  %s  %s\n", labelStyle.Render("RAM"), memBar, formatBytes(memUsage)))
+	content.WriteString(fmt.Sprintf("%s  %s  %s\n", labelStyle.Render("RAM"), memBar, format.Bytes(memUsage)))
 
-	content.WriteString(fmt.Sprintf("%s  %s\n", labelStyle.Render("Limit"), valueStyle.Render(formatBytes(memLimit))))
+	limit := valueStyle.Render(format.Bytes(memLimit))
+	if memPercent >= nearCapPercent {
+		limit = warningStyle.Render(format.Bytes(memLimit) + "  near cap, [u] to raise")
+	}
+	content.WriteString(fmt.Sprintf("%s  %s\n", labelStyle.Render("Limit"), limit))
 
 	if m.stats != nil {
 		content.WriteString(fmt.Sprintf("%s  %s", labelStyle.Render("PIDs"), valueStyle.Render(fmt.Sprintf("%d", m.stats.PidsStats.Current))))
@@ -276,14 +416,73 @@ func (m model) renderInfoBox(width int) string {
 	return boxStyle.Width(width).Render(content.String())
 }
 
+// How many probe results the health box shows, and how many lines of
+// output for each.
+const (
+	healthLogEntries  = 5
+	healthOutputLines = 3
+)
+
+func (m model) renderHealthBox(width int) string {
+	var content strings.Builder
+	content.WriteString(boxTitleStyle.Render("HEALTH"))
+	content.WriteString("\n\n")
+
+	health := m.inspect.State.Health
+	status := valueStyle.Render(string(health.Status))
+	switch health.Status {
+	case container.Healthy:
+		status = runningStyle.Render(string(health.Status))
+	case container.Unhealthy:
+		status = stoppedStyle.Render(string(health.Status))
+	case container.Starting:
+		status = warningStyle.Render(string(health.Status))
+	}
+	content.WriteString(fmt.Sprintf("%-12s  %s\n", labelStyle.Render("Status"), status))
+	content.WriteString(fmt.Sprintf("%-12s  %s", labelStyle.Render("Failing"), valueStyle.Render(fmt.Sprintf("%d in a row", health.FailingStreak))))
+
+	// Log is oldest first, show the most recent probes on top.
+	shown := 0
+	for i := len(health.Log) - 1; i >= 0 && shown < healthLogEntries; i-- {
+		probe := health.Log[i]
+		if probe == nil {
+			continue
+		}
+		shown++
+
+		code := runningStyle.Render(fmt.Sprintf("exit %d", probe.ExitCode))
+		if probe.ExitCode != 0 {
+			code = stoppedStyle.Render(fmt.Sprintf("exit %d", probe.ExitCode))
+		}
+		took := probe.End.Sub(probe.Start).Round(time.Millisecond)
+		content.WriteString(fmt.Sprintf("\n\n%s  %s  %s",
+			labelStyle.Render(probe.Start.Local().Format("15:04:05")), code, labelStyle.Render(took.String())))
+
+		output := strings.TrimSpace(probe.Output)
+		if output == "" {
+			continue
+		}
+		lines := strings.Split(output, "\n")
+		if len(lines) > healthOutputLines {
+			lines = append(lines[:healthOutputLines], "...")
+		}
+		for _, line := range lines {
+			content.WriteString("\n")
+			content.WriteString(valueStyle.Render("  " + format.Truncate(line, width-8)))
+		}
+	}
+
+	return boxStyle.Width(width).Render(content.String())
+}
+
 func (m model) renderMountsBox(width int) string {
 	var content strings.Builder
 	content.WriteString(boxTitleStyle.Render("MOUNTS"))
 	content.WriteString("\n\n")
 
 	for _, mount := range m.inspect.Mounts {
-		src := truncate(mount.Source, 30)
-		dst := truncate(mount.Destination, 30)
+		src := format.Truncate(mount.Source, 30)
+		dst := format.Truncate(mount.Destination, 30)
 		mode := "rw"
 		if !mount.RW {
 			mode = "ro"
@@ -302,7 +501,7 @@ func (m model) renderEnvBox(width int) string {
 	content.WriteString("\n\n")
 
 	for _, env := range m.inspect.Config.Env {
-		content.WriteString(valueStyle.Render(truncate(env, width-4)))
+		content.WriteString(valueStyle.Render(format.Truncate(env, width-4)))
 		content.WriteString("\n")
 	}
 
@@ -316,7 +515,7 @@ func (m model) renderLabelsBox(width int) string {
 
 	for k, v := range m.inspect.Config.Labels {
 		line := fmt.Sprintf("%s=%s", k, v)
-		content.WriteString(valueStyle.Render(truncate(line, width-4)))
+		content.WriteString(valueStyle.Render(format.Truncate(line, width-4)))
 		content.WriteString("\n")
 	}
 
@@ -324,29 +523,6 @@ func (m model) renderLabelsBox(width int) string {
 }
 
 // Helpers
-func calculateCPUPercent(stats *container.StatsResponse) float64 {
-	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage - stats.PreCPUStats.CPUUsage.TotalUsage)
-	systemDelta := float64(stats.CPUStats.SystemUsage - stats.PreCPUStats.SystemUsage)
-
-	if systemDelta > 0 && cpuDelta > 0 {
-		return (cpuDelta / systemDelta) * float64(stats.CPUStats.OnlineCPUs) * 100.0
-	}
-	return 0
-}
-
-func formatBytes(b uint64) string {
-	const unit = 1024
-	if b < unit {
-		return fmt.Sprintf("%d B", b)
-	}
-	div, exp := uint64(unit), 0
-	for n := b / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
-}
-
 func formatTime(t string) string {
 	parsed, err := time.Parse(time.RFC3339Nano, t)
 	if err != nil {
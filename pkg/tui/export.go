@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m model) updateExport(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			m.status = ""
+			m.exportOverwrite = false
+			m.viewport.SetContent(m.renderDetailContent())
+			m.viewport.GotoTop()
+			return m, nil
+		case key.Matches(msg, keys.Save):
+			return m.saveExport(), nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m model) viewExport() string {
+	var b strings.Builder
+
+	b.WriteString(m.viewport.View())
+	b.WriteString("\n")
+
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+	}
+
+	help := "[↑↓] scroll  [w]rite files  [esc]back  [q]uit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) renderExportContent() string {
+	if m.inspect == nil {
+		return ""
+	}
+	spec := docker.SpecFromInspect(*m.inspect, m.imageConfig)
+
+	var b strings.Builder
+	b.WriteString(boxTitleStyle.Render("DOCKER RUN"))
+	b.WriteString("\n\n")
+	b.WriteString(spec.RunScript())
+	b.WriteString("\n\n")
+	b.WriteString(boxTitleStyle.Render("COMPOSE.YAML"))
+	b.WriteString("\n\n")
+	b.WriteString(spec.ComposeFile())
+	return b.String()
+}
+
+// saveExport writes both renderings next to where stackr was started and
+// reports what happened in the status line. Existing files are only
+// overwritten when save is pressed a second time.
+func (m model) saveExport() model {
+	if m.inspect == nil {
+		return m
+	}
+	spec := docker.SpecFromInspect(*m.inspect, m.imageConfig)
+
+	runFile := spec.Name + ".run.sh"
+	composeFile := spec.Name + ".compose.yaml"
+
+	if !m.exportOverwrite {
+		var existing []string
+		for _, f := range []string{runFile, composeFile} {
+			if _, err := os.Stat(f); err == nil {
+				existing = append(existing, f)
+			}
+		}
+		if len(existing) > 0 {
+			m.exportOverwrite = true
+			m.status = strings.Join(existing, " and ") + " already exist, press [w] again to overwrite"
+			return m
+		}
+	}
+	m.exportOverwrite = false
+
+	if err := os.WriteFile(runFile, []byte("#!/bin/sh\n"+spec.RunScript()+"\n"), 0o755); err != nil {
+		m.status = "Error: " + err.Error()
+		return m
+	}
+	if err := os.WriteFile(composeFile, []byte(spec.ComposeFile()), 0o644); err != nil {
+		m.status = "Error: " + err.Error()
+		return m
+	}
+	m.status = fmt.Sprintf("Wrote %s and %s", runFile, composeFile)
+	return m
+}
@@ -0,0 +1,344 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/moby/moby/api/types/container"
+)
+
+// previewLimit caps how much of a file is pulled for the preview.
+const previewLimit = 256 * 1024
+
+type filesMode int
+
+const (
+	filesBrowse filesMode = iota
+	filesChanges
+)
+
+// fileBrowser holds the state of the files view. The cursor indexes into
+// entries or changes depending on mode.
+type fileBrowser struct {
+	mode       filesMode
+	dir        string
+	entries    []docker.FileEntry
+	truncated  bool
+	changes    []container.FilesystemChange
+	cursor     int
+	previewing bool
+}
+
+type dirMsg struct {
+	dir       string
+	entries   []docker.FileEntry
+	truncated bool
+}
+type changesMsg []container.FilesystemChange
+type previewMsg struct {
+	path    string
+	content string
+}
+type filesStatusMsg string
+
+func (m model) enterFiles() (model, tea.Cmd) {
+	m.view = viewFiles
+	m.status = ""
+	m.files = fileBrowser{dir: "/"}
+	return m, tea.Batch(m.listDir("/"), m.fetchChanges)
+}
+
+func (m model) updateFiles(msg tea.Msg) (tea.Model, tea.Cmd) {
+	f := &m.files
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if f.previewing {
+			if key.Matches(msg, keys.Back) {
+				f.previewing = false
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			m.status = ""
+			m.viewport.SetContent(m.renderDetailContent())
+			return m, nil
+		case key.Matches(msg, keys.Up):
+			if f.cursor > 0 {
+				f.cursor--
+			}
+		case key.Matches(msg, keys.Down):
+			if f.cursor < m.filesCount()-1 {
+				f.cursor++
+			}
+		case key.Matches(msg, keys.Toggle):
+			if f.mode == filesBrowse {
+				f.mode = filesChanges
+			} else {
+				f.mode = filesBrowse
+			}
+			f.cursor = 0
+		case key.Matches(msg, keys.Parent):
+			if f.mode == filesBrowse && f.dir != "/" {
+				return m, m.listDir(path.Dir(f.dir))
+			}
+		case key.Matches(msg, keys.Enter):
+			p, isDir, ok := m.filesSelected()
+			if !ok {
+				return m, nil
+			}
+			if isDir {
+				return m, m.listDir(p)
+			}
+			m.status = "Loading " + p + "..."
+			return m, m.openPath(p)
+		case key.Matches(msg, keys.Save):
+			if p, _, ok := m.filesSelected(); ok {
+				m.status = "Downloading " + p + "..."
+				return m, m.downloadPath(p)
+			}
+		case key.Matches(msg, keys.Upload):
+			m.view = viewUpload
+			m.status = ""
+			m.upload = upload{}
+			m.form = newUploadForm(f.dir)
+			return m, textinput.Blink
+		case key.Matches(msg, keys.Refresh):
+			return m, tea.Batch(m.listDir(f.dir), m.fetchChanges)
+		}
+
+	case dirMsg:
+		f.mode = filesBrowse
+		f.dir = msg.dir
+		f.entries = msg.entries
+		f.truncated = msg.truncated
+		f.cursor = 0
+		m.status = ""
+	case changesMsg:
+		f.changes = msg
+	case previewMsg:
+		f.previewing = true
+		m.status = ""
+		m.viewport.SetContent(boxTitleStyle.Render(msg.path) + "\n\n" + msg.content)
+		m.viewport.GotoTop()
+	case filesStatusMsg:
+		m.status = string(msg)
+	}
+
+	return m, nil
+}
+
+func (m model) filesCount() int {
+	if m.files.mode == filesChanges {
+		return len(m.files.changes)
+	}
+	return len(m.files.entries)
+}
+
+// filesSelected returns the absolute path under the cursor and whether it
+// is known to be a directory. Symlinks and paths from the diff may be one
+// too, openPath finds out. Deleted paths from the diff can't be opened.
+func (m model) filesSelected() (string, bool, bool) {
+	f := m.files
+	if f.cursor < 0 || f.cursor >= m.filesCount() {
+		return "", false, false
+	}
+	if f.mode == filesChanges {
+		c := f.changes[f.cursor]
+		if c.Kind == container.ChangeDelete {
+			return "", false, false
+		}
+		return c.Path, false, true
+	}
+	e := f.entries[f.cursor]
+	return path.Join(f.dir, e.Name), e.IsDir(), true
+}
+
+func (m model) viewFiles() string {
+	f := m.files
+	var b strings.Builder
+
+	if f.previewing {
+		b.WriteString(m.viewport.View())
+		b.WriteString("\n")
+		help := "[↑↓] scroll  [esc]close  [q]uit"
+		b.WriteString(helpStyle.Render(help))
+		return b.String()
+	}
+
+	name := ""
+	if m.inspect != nil {
+		name = strings.TrimPrefix(m.inspect.Name, "/")
+	}
+	title := titleStyle.Render("⬡ FILES " + name)
+	var sub string
+	if f.mode == filesBrowse {
+		sub = statusStyle.Render("  " + f.dir)
+		if f.truncated {
+			sub += warningStyle.Render("  (listing truncated)")
+		}
+	} else {
+		sub = statusStyle.Render(fmt.Sprintf("  %d changes since creation", len(f.changes)))
+	}
+	b.WriteString(title + sub + "\n\n")
+
+	visibleLines := max(m.height-7, 5)
+	offset := 0
+	if f.cursor >= visibleLines {
+		offset = f.cursor - visibleLines + 1
+	}
+	end := min(offset+visibleLines, m.filesCount())
+
+	if m.filesCount() == 0 {
+		b.WriteString(statusStyle.Render("  Nothing here.\n"))
+	}
+	for i := offset; i < end; i++ {
+		var line string
+		if f.mode == filesChanges {
+			line = m.renderChangeLine(f.changes[i])
+		} else {
+			line = renderEntryLine(f.entries[i])
+		}
+		if i == f.cursor {
+			b.WriteString(selectedStyle.Render("▸ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	if m.status != "" {
+		b.WriteString("\n")
+		b.WriteString(statusStyle.Render("  " + m.status))
+	}
+
+	b.WriteString("\n")
+	help := "[↑↓] select  [enter] open  [backspace] up  [tab] browse/changes  [w] download tar  [U]pload  [f]refresh  [esc]back  [q]uit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func renderEntryLine(e docker.FileEntry) string {
+	name := e.Name
+	size := format.Bytes(uint64(max(e.Size, 0)))
+	switch {
+	case e.IsDir():
+		name += "/"
+		size = ""
+	case e.LinkTarget != "":
+		name += " → " + e.LinkTarget
+	}
+	return fmt.Sprintf("%s  %10s  %s  %s", e.Mode.String(), size, e.ModTime.Local().Format("2006-01-02 15:04"), name)
+}
+
+func (m model) renderChangeLine(c container.FilesystemChange) string {
+	kind := c.Kind.String()
+	switch c.Kind {
+	case container.ChangeAdd:
+		kind = runningStyle.Render(kind)
+	case container.ChangeDelete:
+		kind = stoppedStyle.Render(kind)
+	default:
+		kind = warningStyle.Render(kind)
+	}
+	return kind + "  " + c.Path
+}
+
+func (m model) listDir(dir string) tea.Cmd {
+	id := m.detailID()
+	return func() tea.Msg {
+		entries, truncated, err := m.client.ListDir(context.Background(), id, dir)
+		if err != nil {
+			return filesStatusMsg("Error: " + err.Error())
+		}
+		return dirMsg{dir: dir, entries: entries, truncated: truncated}
+	}
+}
+
+func (m model) fetchChanges() tea.Msg {
+	changes, err := m.client.Diff(context.Background(), m.detailID())
+	if err != nil {
+		return filesStatusMsg("Error: " + err.Error())
+	}
+	return changesMsg(changes)
+}
+
+// openPath lists p if it turns out to be a directory, following symlinks,
+// and previews it otherwise.
+func (m model) openPath(p string) tea.Cmd {
+	id := m.detailID()
+	return func() tea.Msg {
+		ctx := context.Background()
+		resolved, stat, err := m.client.ResolvePath(ctx, id, p)
+		if err != nil {
+			return filesStatusMsg("Error: " + err.Error())
+		}
+		if stat.Mode.IsDir() {
+			entries, truncated, err := m.client.ListDir(ctx, id, p)
+			if err != nil {
+				return filesStatusMsg("Error: " + err.Error())
+			}
+			return dirMsg{dir: p, entries: entries, truncated: truncated}
+		}
+
+		data, truncated, err := m.client.ReadFile(ctx, id, resolved, previewLimit)
+		if err != nil {
+			return filesStatusMsg("Error: " + err.Error())
+		}
+		content := string(data)
+		if bytes.IndexByte(data, 0) >= 0 {
+			content = statusStyle.Render("Binary file, download it with [w] instead.")
+		} else if truncated {
+			content += "\n" + warningStyle.Render(fmt.Sprintf("... truncated at %s", format.Bytes(previewLimit)))
+		}
+		return previewMsg{path: p, content: content}
+	}
+}
+
+// downloadPath saves p as <name>.tar in the working directory, unless a
+// file of that name is already there.
+func (m model) downloadPath(p string) tea.Cmd {
+	id := m.detailID()
+	return func() tea.Msg {
+		name := path.Base(p)
+		if name == "/" || name == "." {
+			name = "rootfs"
+		}
+		out := name + ".tar"
+
+		file, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			return filesStatusMsg("Error: " + out + " already exists, move it away first")
+		}
+		if err != nil {
+			return filesStatusMsg("Error: " + err.Error())
+		}
+		n, err := m.client.Download(context.Background(), id, p, file)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(out)
+			return filesStatusMsg("Error: " + err.Error())
+		}
+		return filesStatusMsg(fmt.Sprintf("Saved %s (%s)", out, format.Bytes(uint64(n))))
+	}
+}
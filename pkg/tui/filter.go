@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/notes"
+	"github.com/moby/moby/api/types/container"
+)
+
+// listFilter narrows down the containers shown in the list view. The cursor
+// always indexes into the filtered slice.
+type listFilter struct {
+	unhealthy bool
+	// query is the search typed after /. Every word must match, see
+	// matchTerm.
+	query string
+}
+
+func (f listFilter) active() bool {
+	return f.unhealthy || f.query != ""
+}
+
+func (f listFilter) describe() string {
+	var parts []string
+	if f.unhealthy {
+		parts = append(parts, "unhealthy only")
+	}
+	if f.query != "" {
+		parts = append(parts, "matching \""+f.query+"\"")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (f listFilter) match(c container.Summary, note notes.Note) bool {
+	if f.unhealthy && (c.Health == nil || c.Health.Status != container.Unhealthy) {
+		return false
+	}
+	for _, term := range strings.Fields(strings.ToLower(f.query)) {
+		if !matchTerm(c, note, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTerm reports whether a lowercased search term matches the container.
+// "#tag" only matches that tag; anything else matches part of the name,
+// image, compose project, note or a tag, or the start of the ID.
+func matchTerm(c container.Summary, note notes.Note, term string) bool {
+	if tag, ok := strings.CutPrefix(term, "#"); ok && tag != "" {
+		return note.HasTag(tag)
+	}
+	if strings.HasPrefix(c.ID, term) {
+		return true
+	}
+	fields := []string{containerName(c), c.Image, projectOf(c), note.Text}
+	fields = append(fields, note.Tags...)
+	for _, s := range fields {
+		if strings.Contains(strings.ToLower(s), term) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m model) visibleContainers() []container.Summary {
+	containers := m.containers
+	if m.grouped {
+		containers = groupContainers(containers)
+	}
+	if !m.filter.active() {
+		return containers
+	}
+	var out []container.Summary
+	for _, c := range containers {
+		if m.filter.match(c, m.notes.Get(c.ID)) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// formErrMsg reports a failed submit back to the form that issued it.
+type formErrMsg error
+
+// form is a vertical list of labelled text inputs shared by the views that
+// need user input. It only handles focus and editing; submitting is left to
+// the view that owns it. Fields made multiline take one entry per line in a
+// text area instead of their input.
+type form struct {
+	title  string
+	labels []string
+	inputs []textinput.Model
+	areas  map[int]textarea.Model
+	focus  int
+	err    error
+}
+
+func newForm(title string, labels ...string) form {
+	f := form{title: title, labels: labels}
+	for range labels {
+		in := textinput.New()
+		in.Prompt = ""
+		in.CharLimit = 512
+		f.inputs = append(f.inputs, in)
+	}
+	if len(f.inputs) > 0 {
+		f.inputs[0].Focus()
+	}
+	return f
+}
+
+// multiline turns field i into a text area of the given height, for lists
+// whose entries may contain commas, like environment variables.
+func (f *form) multiline(i, height int) {
+	a := textarea.New()
+	a.Prompt = ""
+	a.ShowLineNumbers = false
+	a.CharLimit = 4096
+	a.SetHeight(height)
+	a.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	a.Placeholder = f.inputs[i].Placeholder
+	a.SetValue(f.inputs[i].Value())
+	if i == f.focus {
+		a.Focus()
+	}
+	if f.areas == nil {
+		f.areas = map[int]textarea.Model{}
+	}
+	f.areas[i] = a
+}
+
+func (f *form) setPlaceholder(i int, s string) {
+	if a, ok := f.areas[i]; ok {
+		a.Placeholder = s
+		f.areas[i] = a
+		return
+	}
+	f.inputs[i].Placeholder = s
+}
+
+func (f *form) setValue(i int, s string) {
+	if a, ok := f.areas[i]; ok {
+		a.SetValue(s)
+		f.areas[i] = a
+		return
+	}
+	f.inputs[i].SetValue(s)
+}
+
+func (f form) raw(i int) string {
+	if a, ok := f.areas[i]; ok {
+		return a.Value()
+	}
+	return f.inputs[i].Value()
+}
+
+func (f form) value(i int) string {
+	return strings.TrimSpace(f.raw(i))
+}
+
+// lines splits a multiline field, one entry per line, dropping empty ones.
+func (f form) lines(i int) []string {
+	var out []string
+	for _, s := range strings.Split(f.raw(i), "\n") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// list splits a comma separated field, dropping empty entries.
+func (f form) list(i int) []string {
+	var out []string
+	for _, s := range strings.Split(f.inputs[i].Value(), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (f form) update(msg tea.Msg) (form, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, keys.NextField):
+			return f.moveFocus(1), nil
+		case key.Matches(msg, keys.PrevField):
+			return f.moveFocus(-1), nil
+		}
+	}
+
+	var cmd tea.Cmd
+	if a, ok := f.areas[f.focus]; ok {
+		f.areas = maps.Clone(f.areas)
+		f.areas[f.focus], cmd = a.Update(msg)
+		return f, cmd
+	}
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return f, cmd
+}
+
+func (f form) moveFocus(delta int) form {
+	f.areas = maps.Clone(f.areas)
+	f.blur(f.focus)
+	f.focus = (f.focus + delta + len(f.inputs)) % len(f.inputs)
+	if a, ok := f.areas[f.focus]; ok {
+		a.Focus()
+		f.areas[f.focus] = a
+	} else {
+		f.inputs[f.focus].Focus()
+	}
+	return f
+}
+
+func (f form) blur(i int) {
+	if a, ok := f.areas[i]; ok {
+		a.Blur()
+		f.areas[i] = a
+		return
+	}
+	f.inputs[i].Blur()
+}
+
+func (f form) view(width int) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(f.title))
+	b.WriteString("\n\n")
+
+	labelWidth := 0
+	for _, l := range f.labels {
+		labelWidth = max(labelWidth, len(l))
+	}
+
+	for i, in := range f.inputs {
+		in.Width = max(width-labelWidth-8, 10)
+		indicator := "  "
+		label := labelStyle.Render(fmt.Sprintf("%-*s", labelWidth, f.labels[i]))
+		if i == f.focus {
+			indicator = "▸ "
+			label = selectedStyle.Render(fmt.Sprintf("%-*s", labelWidth, f.labels[i]))
+		}
+		field := in.View()
+		if a, ok := f.areas[i]; ok {
+			a.SetWidth(in.Width)
+			field = a.View()
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, indicator+label+"  ", field) + "\n")
+	}
+
+	if f.err != nil {
+		b.WriteString("\n")
+		b.WriteString(stoppedStyle.Render("  " + f.err.Error()))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type imagesMsg map[string]docker.ImageCheck
+type imageUpdatedMsg struct {
+	oldID, newID string
+	image        string
+	err          error
+}
+
+func (m model) updateImages(msg tea.Msg) (model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case imagesMsg:
+		m.images = msg
+		outdated := 0
+		for _, c := range msg {
+			if c.State == docker.ImageOutdated {
+				outdated++
+			}
+		}
+		if outdated == 0 {
+			m.status = "All images are up to date."
+		} else {
+			m.status = fmt.Sprintf("%d containers run an outdated image, [P] to pull & recreate.", outdated)
+		}
+	case imageUpdatedMsg:
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+			return m, m.fetchContainers
+		}
+		check := m.images[msg.oldID]
+		check.State = docker.ImageCurrent
+		delete(m.images, msg.oldID)
+		m.images[msg.newID] = check
+		if m.selectedID == msg.oldID {
+			m.selectedID = msg.newID
+		}
+		m.status = "Updated to the latest " + msg.image
+		return m, m.fetchContainers
+	}
+	return m, nil
+}
+
+// outdated reports whether the image check found a newer image for the
+// container.
+func (m model) outdated(id string) bool {
+	return m.images[id].State == docker.ImageOutdated
+}
+
+func (m model) checkImages() tea.Msg {
+	return imagesMsg(m.client.CheckImages(context.Background(), m.containers))
+}
+
+// pullAndRecreate pulls the newer image and recreates the container on it
+// with its current configuration, see docker.Reconfigure.
+func (m model) pullAndRecreate(id string) tea.Cmd {
+	image := m.images[id].Image
+	return func() tea.Msg {
+		ctx := context.Background()
+		if err := m.client.Pull(ctx, image); err != nil {
+			return imageUpdatedMsg{oldID: id, image: image, err: err}
+		}
+		ins, err := m.client.Inspect(ctx, id)
+		if err != nil {
+			return imageUpdatedMsg{oldID: id, image: image, err: err}
+		}
+		// The config of the image it ran before the pull, what the new one
+		// must not inherit.
+		img, err := m.client.ImageConfig(ctx, ins)
+		if err != nil {
+			return imageUpdatedMsg{oldID: id, image: image, err: err}
+		}
+		newID, err := m.client.Reconfigure(ctx, id, docker.SpecFromInspect(ins, img))
+		if err == nil {
+			_ = m.notes.Move(id, newID)
+		}
+		return imageUpdatedMsg{oldID: id, newID: newID, image: image, err: err}
+	}
+}
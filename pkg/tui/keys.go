@@ -13,6 +13,44 @@ type keyMap struct {
 	Delete  key.Binding
 	Refresh key.Binding
 	Quit    key.Binding
+
+	New       key.Binding
+	NextField key.Binding
+	PrevField key.Binding
+	Submit    key.Binding
+	Export    key.Binding
+	Save      key.Binding
+	Recreate  key.Binding
+	Limits    key.Binding
+	Unhealthy key.Binding
+	Signal    key.Binding
+	Files     key.Binding
+	Toggle    key.Binding
+	Parent    key.Binding
+	Upload    key.Binding
+	Dismiss   key.Binding
+	Charts    key.Binding
+	Images    key.Binding
+	Pull      key.Binding
+	Group     key.Binding
+
+	StackStart key.Binding
+	StackStop  key.Binding
+	System     key.Binding
+	Prune      key.Binding
+	Confirm    key.Binding
+	Cancel     key.Binding
+	Wizard     key.Binding
+	Left       key.Binding
+	Right      key.Binding
+	Rename     key.Binding
+	Notes      key.Binding
+	Search     key.Binding
+	Ports      key.Binding
+	Copy       key.Binding
+	Probe      key.Binding
+	Yank       key.Binding
+	StatsTable key.Binding
 }
 
 var keys = keyMap{
@@ -56,4 +94,148 @@ var keys = keyMap{
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("q", "quit"),
 	),
+	New: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new"),
+	),
+	NextField: key.NewBinding(
+		key.WithKeys("tab", "down"),
+		key.WithHelp("tab", "next field"),
+	),
+	PrevField: key.NewBinding(
+		key.WithKeys("shift+tab", "up"),
+		key.WithHelp("shift+tab", "previous field"),
+	),
+	Submit: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "submit"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "write files"),
+	),
+	Recreate: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "edit & recreate"),
+	),
+	Limits: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "update limits"),
+	),
+	Unhealthy: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "unhealthy only"),
+	),
+	Signal: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "send signal"),
+	),
+	Files: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "files"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "switch"),
+	),
+	Parent: key.NewBinding(
+		key.WithKeys("backspace"),
+		key.WithHelp("backspace", "parent"),
+	),
+	Upload: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "upload"),
+	),
+	Dismiss: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "dismiss alert"),
+	),
+	Charts: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "charts"),
+	),
+	Images: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "check image updates"),
+	),
+	Pull: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "pull & recreate"),
+	),
+	Group: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "group by stack"),
+	),
+	StackStart: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "start stack"),
+	),
+	StackStop: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "stop stack"),
+	),
+	System: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "system info"),
+	),
+	Prune: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "prune"),
+	),
+	Confirm: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "yes"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("n", "esc"),
+		key.WithHelp("n", "no"),
+	),
+	Wizard: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "prune wizard"),
+	),
+	Left: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/h", "previous"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("right", "l", " "),
+		key.WithHelp("→/l", "next"),
+	),
+	Rename: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "rename"),
+	),
+	Notes: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "notes & tags"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	Ports: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "ports"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy"),
+	),
+	Probe: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "probe"),
+	),
+	Yank: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy"),
+	),
+	StatsTable: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "stats of all containers"),
+	),
 }
\ No newline at end of file
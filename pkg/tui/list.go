@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
 	//"github.com/charmbracelet/lipgloss"
@@ -13,32 +15,82 @@ import (
 )
 
 func (m model) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.searching {
+		return m.updateSearch(msg)
+	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
+		case key.Matches(msg, keys.Search):
+			m.searching = true
+			m.search.SetValue(m.filter.query)
+			m.search.CursorEnd()
+			return m, m.search.Focus()
+		case key.Matches(msg, keys.Back):
+			if m.filter.query != "" {
+				m.filter.query = ""
+				m.restoreSelection()
+			}
 		case key.Matches(msg, keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
+				m.selectCursor()
 			}
 		case key.Matches(msg, keys.Down):
-			if m.cursor < len(m.containers)-1 {
+			if m.cursor < len(m.visibleContainers())-1 {
 				m.cursor++
+				m.selectCursor()
 			}
 		case key.Matches(msg, keys.Enter):
-			if len(m.containers) > 0 {
-				m.view = viewDetail
-				return m, m.fetchContainerDetail
+			if m.selectedID != "" {
+				return m.enterDetail()
 			}
 		case key.Matches(msg, keys.Refresh):
 			return m, m.fetchContainers
+		case key.Matches(msg, keys.Unhealthy):
+			m.filter.unhealthy = !m.filter.unhealthy
+			m.restoreSelection()
+		case key.Matches(msg, keys.Dismiss):
+			m.alert = nil
+		case key.Matches(msg, keys.Group):
+			m.grouped = !m.grouped
+			m.restoreSelection()
+			return m, m.refreshDrift()
+		case key.Matches(msg, keys.System):
+			return m.enterSystem()
+		case key.Matches(msg, keys.Yank):
+			return m.enterYank()
+		case key.Matches(msg, keys.StatsTable):
+			return m.enterStatsTable()
+		case key.Matches(msg, keys.StackStart):
+			return m.startStackAction(true)
+		case key.Matches(msg, keys.StackStop):
+			return m.startStackAction(false)
+		case key.Matches(msg, keys.Images):
+			m.status = "Checking registries for newer images..."
+			return m, m.checkImages
+		case key.Matches(msg, keys.Pull):
+			if m.selectedID == "" {
+				return m, nil
+			}
+			if !m.outdated(m.selectedID) {
+				m.status = "No newer image known for this container, [i] to check."
+				return m, nil
+			}
+			m.status = "Pulling " + m.images[m.selectedID].Image + " and recreating, waiting for it to come up..."
+			return m, m.pullAndRecreate(m.selectedID)
+		case key.Matches(msg, keys.New):
+			m.view = viewCreate
+			m.form = newCreateForm()
+			return m, textinput.Blink
 		case key.Matches(msg, keys.Stop):
-			return m, m.stopContainer
+			return m, m.stopContainer(m.selectedID)
 		case key.Matches(msg, keys.Start):
-			return m, m.startContainer
+			return m, m.startContainer(m.selectedID)
 		case key.Matches(msg, keys.Restart):
-			return m, m.restartContainer
+			return m, m.restartContainer(m.selectedID)
 		case key.Matches(msg, keys.Delete):
-			return m, m.deleteContainer
+			return m, m.deleteContainer(m.selectedID)
 		}
 	case actionDoneMsg:
 		return m, m.fetchContainers
@@ -46,15 +98,68 @@ func (m model) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateSearch edits the search while it has focus. The list filters as
+// the query is typed; enter keeps it, esc clears it.
+func (m model) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, keys.Enter):
+			m.searching = false
+			m.search.Blur()
+			return m, nil
+		case key.Matches(msg, keys.Back):
+			m.searching = false
+			m.search.Blur()
+			m.filter.query = ""
+			m.restoreSelection()
+			return m, nil
+		}
+	}
+	if _, ok := msg.(actionDoneMsg); ok {
+		return m, m.fetchContainers
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.filter.query = strings.TrimSpace(m.search.Value())
+	m.restoreSelection()
+	return m, cmd
+}
+
 func (m model) viewList() string {
 	var b strings.Builder
 
 	// Title
 	title := titleStyle.Render("⬡ STACKR")
-	count := statusStyle.Render(fmt.Sprintf("  %d containers", len(m.containers)))
+	items := m.visibleContainers()
+	running, paused := 0, 0
+	for _, c := range m.containers {
+		switch c.State {
+		case container.StateRunning:
+			running++
+		case container.StatePaused:
+			paused++
+		}
+	}
+	count := statusStyle.Render(fmt.Sprintf("  %d containers  ", len(m.containers))) +
+		runningDot + statusStyle.Render(fmt.Sprintf(" %d running  ", running)) +
+		pausedDot + statusStyle.Render(fmt.Sprintf(" %d paused  ", paused)) +
+		stoppedDot + statusStyle.Render(fmt.Sprintf(" %d stopped", len(m.containers)-running-paused))
+	if desc := m.filter.describe(); desc != "" {
+		count += warningStyle.Render(fmt.Sprintf("  %d shown, %s", len(items), desc))
+	}
+	outdated := 0
+	for _, c := range m.containers {
+		if m.outdated(c.ID) {
+			outdated++
+		}
+	}
+	if outdated > 0 {
+		count += warningStyle.Render(fmt.Sprintf("  %d outdated", outdated))
+	}
 	b.WriteString(title + count + "\n\n")
 
-	if len(m.containers) == 0 {
+	if len(items) == 0 {
 		b.WriteString(statusStyle.Render("  No containers found.\n"))
 	} else {
 		// Calculate visible area
@@ -63,35 +168,52 @@ func (m model) viewList() string {
 			visibleLines = 5
 		}
 
-		// Calculate scroll offset
+		// Calculate scroll offset, in rows since group headers take lines
+		rows, cursorRow := m.listRows(items)
 		offset := 0
-		if m.cursor >= visibleLines {
-			offset = m.cursor - visibleLines + 1
+		if cursorRow >= visibleLines {
+			offset = cursorRow - visibleLines + 1
 		}
 
-		// Render visible containers
-		end := offset + visibleLines
-		if end > len(m.containers) {
-			end = len(m.containers)
-		}
-
-		for i := offset; i < end; i++ {
-			c := m.containers[i]
-			line := m.renderLine(c, i == m.cursor)
-			b.WriteString(line)
+		// Render visible rows
+		end := min(offset+visibleLines, len(rows))
+		for _, row := range rows[offset:end] {
+			b.WriteString(row)
 			b.WriteString("\n")
 		}
 
 		// Scroll indicator
-		if len(m.containers) > visibleLines {
-			indicator := statusStyle.Render(fmt.Sprintf("\n  [%d/%d]", m.cursor+1, len(m.containers)))
+		if len(rows) > visibleLines {
+			indicator := statusStyle.Render(fmt.Sprintf("\n  [%d/%d]", m.cursor+1, len(items)))
 			b.WriteString(indicator)
 		}
 	}
 
+	if m.searching {
+		b.WriteString("\n\n  " + m.search.View())
+	}
+	if m.status != "" {
+		b.WriteString("\n\n")
+		b.WriteString(statusStyle.Render("  " + m.status))
+	}
+	b.WriteString(m.renderAlertBar())
+
 	// Help
 	b.WriteString("\n\n")
-	help := "[↑↓] select  [enter] details  [s]top  [r]esume  [R]estart  [d]elete  [f]refresh  [q]uit"
+	if m.searching {
+		b.WriteString(helpStyle.Render("[enter] keep search  [esc] clear  [ctrl+c] quit"))
+		return b.String()
+	}
+	help := "[↑↓] select  [enter] details  [y]ank  [s]top  [r]esume  [R]estart  [d]elete  [n]ew  [H]unhealthy  [g]roup  [/] search  [i]mage updates  [P]ull  [t] stats  [I] system  [f]refresh  [q]uit"
+	if m.grouped {
+		help = "[A] start stack  [S]top stack  " + help
+	}
+	if m.filter.query != "" {
+		help = "[esc] clear search  " + help
+	}
+	if m.alert != nil {
+		help = "[x] dismiss alert  " + help
+	}
 	b.WriteString(helpStyle.Render(help))
 
 	return b.String()
@@ -108,31 +230,52 @@ func (m model) renderLine(c container.Summary, selected bool) string {
 	dot := statusDot(string(c.State))
 
 	// Name (max 16 chars)
-	name := truncate(containerName(c), 16)
+	name := format.Truncate(containerName(c), 16)
 
-	// Image (max 24 chars)
-	image := truncate(c.Image, 24)
+	// Image (max 24 chars), flagged when a newer one is available
+	image := format.Truncate(c.Image, 24)
+	if m.outdated(c.ID) {
+		image = "↑ " + format.Truncate(c.Image, 22)
+	}
 
 	// Status text (max 16 chars)
-	status := truncate(shortStatus(c.Status), 16)
+	status := format.Truncate(format.ShortStatus(c.Status), 16)
+
+	// Health (blank when the container has no healthcheck)
+	health := healthLabel(c.Health)
 
 	// Ports
-	ports := truncate(formatPorts(c.Ports), 16)
+	ports := format.Truncate(format.Ports(c.Ports), 16)
 
 	// Build line
-	line := fmt.Sprintf("%s%s %-16s  %-24s  %-16s  %s",
+	line := fmt.Sprintf("%s%s %-16s  %-24s  %-16s  %-9s  %-16s",
 		indicator,
 		dot,
 		name,
 		image,
 		status,
+		health,
 		ports,
 	)
 
+	// Notes: a marker when there is text, then the tags
+	if note := m.notes.Get(c.ID); !note.Empty() {
+		marker := " "
+		if note.Text != "" {
+			marker = "✎"
+		}
+		line += "  " + marker + " " + format.Truncate(formatTags(note.Tags), 30)
+	}
+	line = strings.TrimRight(line, " ")
+
 	if selected {
 		return selectedStyle.Render(line)
 	}
 
+	if c.Health != nil && c.Health.Status == container.Unhealthy {
+		return warningStyle.Render(line)
+	}
+
 	// Color based on state
 	if c.State == "running" {
 		return runningStyle.Render(line)
@@ -140,76 +283,72 @@ func (m model) renderLine(c container.Summary, selected bool) string {
 	return stoppedStyle.Render(line)
 }
 
-func shortStatus(status string) string {
-	// Simplify "Up 3 days" -> "Up 3d", "Exited (0) 2 hours ago" -> "Exited"
-	if strings.HasPrefix(status, "Up") {
-		parts := strings.Fields(status)
-		if len(parts) >= 3 {
-			return fmt.Sprintf("Up %s%c", parts[1], parts[2][0])
-		}
-		return status
-	}
-	if strings.HasPrefix(status, "Exited") {
-		return "Exited"
-	}
-	if strings.HasPrefix(status, "Created") {
-		return "Created"
-	}
-	return truncate(status, 12)
-}
-
-func formatPorts(ports []container.PortSummary) string {
-	if len(ports) == 0 {
+func healthLabel(h *container.HealthSummary) string {
+	if h == nil || h.Status == container.NoHealthcheck || h.Status == "" {
 		return ""
 	}
-	var parts []string
-	seen := make(map[string]bool)
-	for _, p := range ports {
-		var key string
-		if p.PublicPort != 0 {
-			key = fmt.Sprintf("%d:%d", p.PublicPort, p.PrivatePort)
-		} else {
-			key = fmt.Sprintf("%d", p.PrivatePort)
-		}
-		if !seen[key] {
-			parts = append(parts, key)
-			seen[key] = true
-		}
-	}
-	return strings.Join(parts, ",")
+	return string(h.Status)
 }
 
 // Actions
-func (m model) stopContainer() tea.Msg {
-	if len(m.containers) == 0 {
-		return nil
-	}
-	_ = m.client.Stop(context.Background(), m.containers[m.cursor].ID)
-	return actionDoneMsg{}
+// Each action is bound to the container ID when the key is pressed, never
+// to m.cursor, so a refresh landing between the keypress and the command
+// can't retarget it. The list passes m.selectedID, the detail view and its
+// sub-views the inspected container.
+func (m model) stopContainer(id string) tea.Cmd {
+	return m.containerAction(id, m.client.Stop)
 }
 
-func (m model) startContainer() tea.Msg {
-	if len(m.containers) == 0 {
-		return nil
+func (m model) startContainer(id string) tea.Cmd {
+	return m.containerAction(id, m.client.Start)
+}
+
+func (m model) restartContainer(id string) tea.Cmd {
+	return m.containerAction(id, m.client.Restart)
+}
+
+func (m model) deleteContainer(id string) tea.Cmd {
+	return m.containerAction(id, m.client.Remove)
+}
+
+func (m model) containerAction(id string, action func(context.Context, string) error) tea.Cmd {
+	return func() tea.Msg {
+		if id == "" {
+			return nil
+		}
+		_ = action(context.Background(), id)
+		return actionDoneMsg{}
 	}
-	_ = m.client.Start(context.Background(), m.containers[m.cursor].ID)
-	return actionDoneMsg{}
 }
 
-func (m model) restartContainer() tea.Msg {
-	if len(m.containers) == 0 {
-		return nil
+// Selection
+func (m *model) selectCursor() {
+	items := m.visibleContainers()
+	if m.cursor >= 0 && m.cursor < len(items) {
+		m.selectedID = items[m.cursor].ID
+		return
 	}
-	_ = m.client.Restart(context.Background(), m.containers[m.cursor].ID)
-	return actionDoneMsg{}
+	m.selectedID = ""
 }
 
-func (m model) deleteContainer() tea.Msg {
-	if len(m.containers) == 0 {
-		return nil
+// restoreSelection moves the cursor back onto the selected container after
+// the list has been reloaded. If it is gone, the cursor stays at the same
+// position (clamped) and selects whatever is there now.
+func (m *model) restoreSelection() {
+	items := m.visibleContainers()
+	for i, c := range items {
+		if c.ID == m.selectedID {
+			m.cursor = i
+			return
+		}
+	}
+	if m.cursor >= len(items) {
+		m.cursor = len(items) - 1
 	}
-	_ = m.client.Remove(context.Background(), m.containers[m.cursor].ID)
-	return actionDoneMsg{}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	m.selectCursor()
 }
 
 // Helpers
@@ -219,13 +358,3 @@ func containerName(c container.Summary) string {
 	}
 	return c.ID[:12]
 }
-
-func truncate(s string, max int) string {
-	if max < 4 {
-		max = 4
-	}
-	if len(s) > max {
-		return s[:max-3] + "..."
-	}
-	return s
-}
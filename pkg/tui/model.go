@@ -1,9 +1,15 @@
 package tui
 
 import (
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
 	"github.com/moby/moby/api/types/container"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/alert"
 	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/history"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/notes"
 )
 
 type viewState int
@@ -11,6 +17,21 @@ type viewState int
 const (
 	viewList viewState = iota
 	viewDetail
+	viewCreate
+	viewExport
+	viewRecreate
+	viewUpdate
+	viewSignal
+	viewFiles
+	viewUpload
+	viewCharts
+	viewSystem
+	viewPrune
+	viewRename
+	viewNotes
+	viewPorts
+	viewYank
+	viewStats
 )
 
 type model struct {
@@ -18,14 +39,59 @@ type model struct {
 	view       viewState
 	containers []container.Summary
 	cursor     int
+	selectedID string
+	filter     listFilter
+	search     textinput.Model
+	searching  bool
+	grouped    bool
+	drift      map[string]stackDrift
 	width      int
 	height     int
 	err        error
+	status     string
 
 	// Detail view data
 	inspect  *container.InspectResponse
 	stats    *container.StatsResponse
+	top      *container.TopResponse
+	topSeq   int
 	viewport viewport.Model
+	// imageConfig is the config of the inspected container's image, nil if
+	// it couldn't be read. See docker.SpecFromInspect.
+	imageConfig *dockerspec.DockerOCIImageConfig
+	// exportOverwrite is set once the export view warned that its files
+	// exist; saving again overwrites them.
+	exportOverwrite bool
+
+	// Form used by the input driven views
+	form form
+
+	files  fileBrowser
+	upload upload
+	charts charts
+	system systemDash
+	prune  pruneWizard
+	ports  portPicker
+	yank   yankMenu
+
+	statsTable statsTable
+
+	// Image update checks by container ID, empty until requested.
+	images map[string]docker.ImageCheck
+
+	// Local notes and tags on containers.
+	notes *notes.Store
+
+	// Recorded stats, nil when history is disabled.
+	history *history.Store
+
+	// Alerts from the rule watcher, nil when no rules are configured. The
+	// latest one stays in the alert bar.
+	alerts <-chan alert.Alert
+	alert  *alert.Alert
+
+	// Steps of the running stack start or stop, nil when idle.
+	stackSteps chan tea.Msg
 }
 
 // Messages
@@ -33,6 +99,7 @@ type containersMsg []container.Summary
 type inspectMsg struct {
 	inspect *container.InspectResponse
 	stats   *container.StatsResponse
+	image   *dockerspec.DockerOCIImageConfig
 }
 type errMsg error
 type actionDoneMsg struct{}
\ No newline at end of file
@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/notes"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type renamedMsg struct {
+	name string
+}
+
+func newRenameForm(name string) form {
+	f := newForm("⬡ RENAME "+name, "Name")
+	f.setValue(0, name)
+	return f
+}
+
+func (m model) updateRename(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			return m, nil
+		case key.Matches(msg, keys.Submit):
+			name := m.form.value(0)
+			if name == "" {
+				m.form.err = fmt.Errorf("name is required")
+				return m, nil
+			}
+			return m, m.renameContainer(m.detailID(), name)
+		}
+	case renamedMsg:
+		m.view = viewDetail
+		m.status = "Renamed to " + msg.name
+		return m, tea.Batch(m.fetchContainerDetail, m.fetchContainers)
+	case formErrMsg:
+		m.form.err = msg
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.form, cmd = m.form.update(msg)
+	return m, cmd
+}
+
+func (m model) viewRename() string {
+	var b strings.Builder
+
+	b.WriteString(m.form.view(m.width))
+
+	help := "[ctrl+s] rename  [esc] cancel  [ctrl+c] quit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) renameContainer(id, name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.client.Rename(context.Background(), id, name); err != nil {
+			return formErrMsg(err)
+		}
+		return renamedMsg{name: name}
+	}
+}
+
+// newNotesForm edits the note and tags stackr keeps locally for a
+// container. Tags are comma separated, without the # used to search them.
+func newNotesForm(name string, note notes.Note) form {
+	f := newForm("⬡ NOTES "+name, "Note", "Tags")
+	f.setValue(0, note.Text)
+	f.setValue(1, strings.Join(note.Tags, ", "))
+	f.setPlaceholder(0, "what this container is for, who owns it...")
+	f.setPlaceholder(1, "billing, staging, on-call")
+	return f
+}
+
+func (m model) updateNotes(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			return m, nil
+		case key.Matches(msg, keys.Submit):
+			var tags []string
+			for _, t := range m.form.list(1) {
+				tags = append(tags, strings.TrimPrefix(t, "#"))
+			}
+			if err := m.notes.Set(m.detailID(), notes.Note{Text: m.form.value(0), Tags: tags}); err != nil {
+				m.form.err = err
+				return m, nil
+			}
+			m.view = viewDetail
+			m.status = "Notes saved"
+			m.viewport.SetContent(m.renderDetailContent())
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.form, cmd = m.form.update(msg)
+	return m, cmd
+}
+
+func (m model) viewNotes() string {
+	var b strings.Builder
+
+	b.WriteString(m.form.view(m.width))
+
+	help := "[tab/↑↓] field  [ctrl+s] save  [esc] cancel  [ctrl+c] quit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) renderNotesBox(width int) string {
+	var content strings.Builder
+	content.WriteString(boxTitleStyle.Render("NOTES"))
+	content.WriteString("\n\n")
+
+	note := m.notes.Get(m.inspect.ID)
+	if note.Text != "" {
+		for _, line := range strings.Split(note.Text, "\n") {
+			content.WriteString(valueStyle.Render(format.Truncate(line, width-4)))
+			content.WriteString("\n")
+		}
+	}
+	if len(note.Tags) > 0 {
+		content.WriteString(labelStyle.Render(formatTags(note.Tags)))
+		content.WriteString("\n")
+	}
+	content.WriteString(statusStyle.Render("updated " + note.Updated.Local().Format("2006-01-02 15:04")))
+
+	return boxStyle.Width(width).Render(content.String())
+}
+
+func formatTags(tags []string) string {
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = "#" + t
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,200 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/probe"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// portPicker is the state of the ports view. probes are by index in ports;
+// seq tells the probes of this visit from those of an earlier one.
+type portPicker struct {
+	ports   []docker.PublishedPort
+	cursor  int
+	probes  map[int]probe.Result
+	probing map[int]bool
+	seq     int
+}
+
+// probeRounding keeps latencies readable without hiding sub-millisecond
+// ones on the local host.
+const probeRounding = 10 * time.Microsecond
+
+type probeMsg struct {
+	seq    int
+	index  int
+	result probe.Result
+}
+
+// enterPorts opens the picker on the published ports of the container in
+// the detail view and probes all of them.
+func (m model) enterPorts() (model, tea.Cmd) {
+	ports := m.client.PublishedPorts(*m.inspect)
+	if len(ports) == 0 {
+		m.status = "No published ports."
+		return m, nil
+	}
+	m.view = viewPorts
+	m.status = ""
+	m.ports = portPicker{
+		ports:   ports,
+		probes:  map[int]probe.Result{},
+		probing: map[int]bool{},
+		seq:     m.ports.seq + 1,
+	}
+	return m, m.probeAll()
+}
+
+func (m model) updatePorts(msg tea.Msg) (tea.Model, tea.Cmd) {
+	p := &m.ports
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		port := p.ports[p.cursor]
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			m.status = ""
+			return m, nil
+		case key.Matches(msg, keys.Up):
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case key.Matches(msg, keys.Down):
+			if p.cursor < len(p.ports)-1 {
+				p.cursor++
+			}
+		case key.Matches(msg, keys.Enter), key.Matches(msg, keys.Ports):
+			if port.URL() == "" {
+				m.status = "Only TCP ports can be opened."
+				return m, nil
+			}
+			return m, openURL(port.URL())
+		case key.Matches(msg, keys.Copy):
+			s := port.URL()
+			if s == "" {
+				s = port.Address()
+			}
+			return m, copyText(s, s)
+		case key.Matches(msg, keys.Probe):
+			return m, m.probePort(p.cursor)
+		case key.Matches(msg, keys.Refresh):
+			return m, m.probeAll()
+		}
+
+	case probeMsg:
+		if msg.seq != p.seq {
+			return m, nil
+		}
+		delete(p.probing, msg.index)
+		p.probes[msg.index] = msg.result
+
+	case copiedMsg:
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+		} else {
+			m.status = "Copied " + msg.what
+		}
+
+	case openedMsg:
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+		} else {
+			m.status = "Opened " + msg.url
+		}
+	}
+	return m, nil
+}
+
+func (m model) probeAll() tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range m.ports.ports {
+		cmds = append(cmds, m.probePort(i))
+	}
+	return tea.Batch(cmds...)
+}
+
+// probePort marks the port as being probed and returns the probe. The maps
+// are shared by every copy of the model, which is fine as only Update
+// touches them.
+func (m model) probePort(i int) tea.Cmd {
+	p := m.ports
+	port, seq := p.ports[i], p.seq
+	if port.Proto != "tcp" {
+		return nil
+	}
+	p.probing[i] = true
+	return func() tea.Msg {
+		return probeMsg{seq: seq, index: i, result: probe.Check(context.Background(), port.Address(), port.URL())}
+	}
+}
+
+func (m model) viewPorts() string {
+	var b strings.Builder
+	p := m.ports
+	name := strings.TrimPrefix(m.inspect.Name, "/")
+	b.WriteString(titleStyle.Render("⬡ PORTS " + name))
+	b.WriteString("\n\n")
+
+	for i, port := range p.ports {
+		target := port.URL()
+		if target == "" {
+			target = port.Address()
+		}
+		line := fmt.Sprintf("%-18s %-36s ", port.String(), format.Truncate(target, 36))
+		if i == p.cursor {
+			b.WriteString(selectedStyle.Render("▸ " + line))
+		} else {
+			b.WriteString(valueStyle.Render("  " + line))
+		}
+		b.WriteString(m.renderProbe(i) + "\n")
+	}
+
+	if m.status != "" {
+		b.WriteString("\n" + statusStyle.Render("  "+m.status) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("[↑↓] select  [enter/o] open  [c]opy URL  [t] probe  [f] probe all  [esc]back  [q]uit"))
+	return b.String()
+}
+
+// renderProbe shows whether the port at index i accepted a connection and
+// what HTTP answered, with the time each took.
+func (m model) renderProbe(i int) string {
+	p := m.ports
+	if p.ports[i].Proto != "tcp" {
+		return statusStyle.Render("not probed")
+	}
+	if p.probing[i] {
+		return statusStyle.Render("probing...")
+	}
+	r, ok := p.probes[i]
+	if !ok {
+		return ""
+	}
+	if r.Err != nil {
+		return stoppedStyle.Render("✗ closed  ") + statusStyle.Render(format.Truncate(r.Err.Error(), 40))
+	}
+	s := runningStyle.Render(fmt.Sprintf("✓ open %s", r.Connect.Round(probeRounding)))
+	switch {
+	case r.HTTPErr != nil:
+		s += statusStyle.Render("  no HTTP answer")
+	case r.Status > 0:
+		code := fmt.Sprintf("  %d %s %s", r.Status, http.StatusText(r.Status), r.Response.Round(probeRounding))
+		if r.Status >= 500 {
+			s += stoppedStyle.Render(code)
+		} else if r.Status >= 400 {
+			s += warningStyle.Render(code)
+		} else {
+			s += runningStyle.Render(code)
+		}
+	}
+	return s
+}
@@ -0,0 +1,317 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The prune wizard goes from choosing what to prune to a preview of every
+// object, then runs and reports on each of them.
+type pruneStep int
+
+const (
+	pruneChoose pruneStep = iota
+	prunePreview
+	pruneRunning
+	pruneReport
+)
+
+type pruneWizard struct {
+	step     pruneStep
+	cursor   int
+	choice   []int // option picked for each of pruneQuestions
+	items    []docker.PruneItem
+	done     int
+	progress chan tea.Msg
+}
+
+type prunePlanMsg struct {
+	items []docker.PruneItem
+	err   error
+}
+type pruneProgressMsg int
+type pruneReportMsg []docker.PruneItem
+
+// pruneQuestions are the wizard's rows. Each sets its part of the options
+// from the index of the picked answer.
+var pruneQuestions = []struct {
+	label   string
+	options []string
+	initial int
+	apply   func(o *docker.PruneOptions, i int)
+}{
+	{"Stopped containers", []string{"keep", "all", "older than 1h", "older than 24h", "older than 7d", "older than 30d"}, 1,
+		func(o *docker.PruneOptions, i int) {
+			o.Containers = i > 0
+			o.OlderThan = []time.Duration{0, 0, time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}[i]
+		}},
+	{"Images", []string{"keep", "dangling", "all unused"}, 1,
+		func(o *docker.PruneOptions, i int) {
+			o.Images = []string{"", docker.PruneDangling, docker.PruneUnused}[i]
+		}},
+	{"Volumes", []string{"keep", "anonymous", "all unused"}, 0,
+		func(o *docker.PruneOptions, i int) {
+			o.Volumes = []string{"", docker.PruneAnonymous, docker.PruneAll}[i]
+		}},
+	{"Networks", []string{"keep", "unused"}, 1,
+		func(o *docker.PruneOptions, i int) { o.Networks = i > 0 }},
+	{"Build cache", []string{"keep", "unused"}, 1,
+		func(o *docker.PruneOptions, i int) { o.BuildCache = i > 0 }},
+}
+
+func (m model) enterPrune() (model, tea.Cmd) {
+	m.view = viewPrune
+	m.status = ""
+	m.prune = pruneWizard{}
+	for _, q := range pruneQuestions {
+		m.prune.choice = append(m.prune.choice, q.initial)
+	}
+	return m, nil
+}
+
+func (w pruneWizard) options() docker.PruneOptions {
+	var o docker.PruneOptions
+	for i, q := range pruneQuestions {
+		q.apply(&o, w.choice[i])
+	}
+	return o
+}
+
+func (m model) updatePrune(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch m.prune.step {
+		case pruneChoose:
+			return m.updatePruneChoose(msg)
+		case prunePreview:
+			switch {
+			case key.Matches(msg, keys.Back):
+				m.prune.step = pruneChoose
+				m.status = ""
+				return m, nil
+			case key.Matches(msg, keys.Enter):
+				if len(m.prune.items) == 0 {
+					return m, nil
+				}
+				m.prune.step = pruneRunning
+				m.prune.progress = make(chan tea.Msg, 1)
+				m.status = "Pruning..."
+				return m, tea.Batch(m.executePrune(), m.waitPrune())
+			}
+		case pruneReport:
+			if key.Matches(msg, keys.Back) {
+				m, cmd := m.enterSystem()
+				return m, cmd
+			}
+		}
+
+	case prunePlanMsg:
+		if m.prune.step != pruneChoose {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+			return m, nil
+		}
+		m.prune.items = msg.items
+		m.prune.step = prunePreview
+		m.status = ""
+		m.viewport.SetContent(m.renderPruneItems())
+		m.viewport.GotoTop()
+		return m, nil
+
+	case pruneProgressMsg:
+		m.prune.done = int(msg)
+		m.status = fmt.Sprintf("Pruning... %d/%d", m.prune.done, len(m.prune.items))
+		return m, m.waitPrune()
+
+	case pruneReportMsg:
+		m.prune.items = msg
+		m.prune.step = pruneReport
+		m.prune.progress = nil
+		failed := 0
+		for _, item := range msg {
+			if item.Err != nil {
+				failed++
+			}
+		}
+		m.status = ""
+		if failed > 0 {
+			m.status = fmt.Sprintf("%d could not be removed.", failed)
+		}
+		m.viewport.SetContent(m.renderPruneItems())
+		m.viewport.GotoTop()
+		return m, nil
+	}
+
+	if m.prune.step != pruneChoose {
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m model) updatePruneChoose(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	w := &m.prune
+	switch {
+	case key.Matches(msg, keys.Back):
+		return m.enterSystem()
+	case key.Matches(msg, keys.Up):
+		if w.cursor > 0 {
+			w.cursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if w.cursor < len(pruneQuestions)-1 {
+			w.cursor++
+		}
+	case key.Matches(msg, keys.Right):
+		n := len(pruneQuestions[w.cursor].options)
+		w.choice[w.cursor] = (w.choice[w.cursor] + 1) % n
+	case key.Matches(msg, keys.Left):
+		n := len(pruneQuestions[w.cursor].options)
+		w.choice[w.cursor] = (w.choice[w.cursor] + n - 1) % n
+	case key.Matches(msg, keys.Enter):
+		m.status = "Looking for unused objects..."
+		return m, m.planPrune(w.options())
+	}
+	return m, nil
+}
+
+func (m model) viewPrune() string {
+	var b strings.Builder
+	w := m.prune
+	if w.step == pruneChoose {
+		b.WriteString(titleStyle.Render("⬡ PRUNE"))
+		b.WriteString(statusStyle.Render("  Choose what to remove, nothing is removed before the preview."))
+		b.WriteString("\n\n")
+		for i, q := range pruneQuestions {
+			var opts []string
+			for j, o := range q.options {
+				if j == w.choice[i] {
+					opts = append(opts, selectedStyle.Render("["+o+"]"))
+				} else {
+					opts = append(opts, statusStyle.Render(" "+o+" "))
+				}
+			}
+			indicator := "  "
+			if i == w.cursor {
+				indicator = "▸ "
+			}
+			b.WriteString(indicator + labelStyle.Render(fmt.Sprintf("%-20s", q.label)) + strings.Join(opts, " ") + "\n")
+		}
+		if m.status != "" {
+			b.WriteString("\n" + statusStyle.Render("  "+m.status))
+		}
+		b.WriteString("\n\n" + helpStyle.Render("[↑↓] select  [←→] change  [enter] preview  [esc]back  [q]uit"))
+		return b.String()
+	}
+
+	b.WriteString(m.viewport.View())
+	b.WriteString("\n")
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+		b.WriteString("\n")
+	}
+	help := "[↑↓] scroll  [esc]back  [q]uit"
+	switch w.step {
+	case prunePreview:
+		if len(w.items) > 0 {
+			help = fmt.Sprintf("[enter] remove these %d  [↑↓] scroll  [esc]back  [q]uit", len(w.items))
+		}
+	case pruneRunning:
+		help = ""
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
+
+// renderPruneItems lists the planned items, or the outcome of each once
+// the prune ran.
+func (m model) renderPruneItems() string {
+	w := m.prune
+	var b strings.Builder
+	title := "⬡ PRUNE PREVIEW"
+	if w.step == pruneReport {
+		title = "⬡ PRUNE REPORT"
+	}
+	b.WriteString(titleStyle.Render(title) + "\n\n")
+	if len(w.items) == 0 {
+		b.WriteString(statusStyle.Render("  Nothing to remove.\n"))
+		return b.String()
+	}
+
+	var size int64
+	removed := 0
+	for _, item := range w.items {
+		name := item.Name
+		if name == "" {
+			name = item.ID
+		}
+		line := fmt.Sprintf("%-12s %-40s %10s", item.Kind, format.Truncate(name, 40), format.Bytes(uint64(item.Size)))
+		switch {
+		case w.step != pruneReport:
+			b.WriteString("  " + valueStyle.Render(line))
+		case item.Err != nil:
+			b.WriteString(stoppedStyle.Render("✗ "+line) + "  " + warningStyle.Render(item.Err.Error()))
+		default:
+			b.WriteString(runningStyle.Render("✓ " + line))
+		}
+		b.WriteString("\n")
+		if item.Err == nil {
+			size += item.Size
+			removed++
+		}
+	}
+
+	b.WriteString("\n")
+	if w.step == pruneReport {
+		b.WriteString(boxTitleStyle.Render(fmt.Sprintf("  Removed %d of %d, freed %s", removed, len(w.items), format.Bytes(uint64(size)))))
+	} else {
+		b.WriteString(boxTitleStyle.Render(fmt.Sprintf("  %d to remove, freeing %s", len(w.items), format.Bytes(uint64(size)))))
+	}
+	return b.String()
+}
+
+func (m model) planPrune(opts docker.PruneOptions) tea.Cmd {
+	return func() tea.Msg {
+		items, err := m.client.PlanPrune(context.Background(), opts)
+		return prunePlanMsg{items: items, err: err}
+	}
+}
+
+// executePrune removes the previewed items and feeds progress into the
+// channel, dropping updates the UI hasn't caught up with.
+func (m model) executePrune() tea.Cmd {
+	items := m.prune.items
+	ch := m.prune.progress
+	return func() tea.Msg {
+		report := m.client.ExecutePrune(context.Background(), items, func(done int) {
+			select {
+			case ch <- pruneProgressMsg(done):
+			default:
+			}
+		})
+		ch <- pruneReportMsg(report)
+		close(ch)
+		return nil
+	}
+}
+
+func (m model) waitPrune() tea.Cmd {
+	ch := m.prune.progress
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
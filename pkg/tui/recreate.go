@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	recreateImage = iota
+	recreateEnv
+	recreatePorts
+	recreateMemory
+	recreateCPUs
+	recreateRestart
+)
+
+type recreatedMsg struct {
+	id string
+}
+
+// newRecreateForm is prefilled from the container's current configuration.
+// Only the fields shown are editable; everything else is carried over from
+// the inspect result as is.
+func newRecreateForm(spec docker.CreateSpec) form {
+	f := newForm("⬡ EDIT & RECREATE "+spec.Name,
+		"Image", "Env", "Ports", "Memory", "CPUs", "Restart")
+	f.setValue(recreateImage, spec.Image)
+	f.multiline(recreateEnv, 4)
+	f.setValue(recreateEnv, strings.Join(spec.Env, "\n"))
+	f.setValue(recreatePorts, strings.Join(spec.Ports, ", "))
+	f.setValue(recreateMemory, spec.Memory)
+	f.setValue(recreateCPUs, spec.CPUs)
+	f.setValue(recreateRestart, spec.Restart)
+	f.setPlaceholder(recreateMemory, "unlimited")
+	f.setPlaceholder(recreateCPUs, "unlimited")
+	f.setPlaceholder(recreateRestart, "no")
+	return f
+}
+
+func (m model) recreateSpec() docker.CreateSpec {
+	spec := docker.SpecFromInspect(*m.inspect, m.imageConfig)
+	spec.Image = m.form.value(recreateImage)
+	spec.Env = m.form.lines(recreateEnv)
+	spec.Ports = m.form.list(recreatePorts)
+	spec.Memory = m.form.value(recreateMemory)
+	spec.CPUs = m.form.value(recreateCPUs)
+	spec.Restart = m.form.value(recreateRestart)
+	return spec
+}
+
+func (m model) updateRecreate(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.status != "" {
+			// Recreate in progress, don't let the form change under it.
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			return m, nil
+		case key.Matches(msg, keys.Submit):
+			spec := m.recreateSpec()
+			if err := spec.Validate(); err != nil {
+				m.form.err = err
+				return m, nil
+			}
+			m.form.err = nil
+			m.status = "Recreating " + spec.Name + ", waiting for it to come up..."
+			return m, m.recreateContainer(m.detailID(), spec)
+		}
+	case recreatedMsg:
+		m.selectedID = msg.id
+		m.status = ""
+		m, cmd := m.enterDetail()
+		return m, tea.Batch(m.fetchContainers, cmd)
+	case formErrMsg:
+		m.form.err = msg
+		m.status = ""
+		return m, m.fetchContainers
+	}
+
+	var cmd tea.Cmd
+	m.form, cmd = m.form.update(msg)
+	return m, cmd
+}
+
+func (m model) viewRecreate() string {
+	var b strings.Builder
+
+	b.WriteString(m.form.view(m.width))
+
+	b.WriteString("\n")
+	b.WriteString(boxStyle.Width(max(m.width-2, 40)).Render(
+		boxTitleStyle.Render("DOCKER RUN") + "\n\n" + valueStyle.Render(m.recreateSpec().RunCommand()),
+	))
+	b.WriteString("\n")
+
+	if m.status != "" {
+		b.WriteString(statusStyle.Render("  " + m.status))
+		b.WriteString("\n")
+	}
+
+	help := "[tab/↑↓] field  [ctrl+s] recreate  [esc] cancel  [ctrl+c] quit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) recreateContainer(id string, spec docker.CreateSpec) tea.Cmd {
+	return func() tea.Msg {
+		newID, err := m.client.Reconfigure(context.Background(), id, spec)
+		if err != nil {
+			return formErrMsg(err)
+		}
+		// Notes belong to what the container is, not to its ID. Failing to
+		// carry them over is no reason to report the recreate as failed.
+		_ = m.notes.Move(id, newID)
+		return recreatedMsg{id: newID}
+	}
+}
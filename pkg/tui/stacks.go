@@ -0,0 +1,255 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/stack"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/moby/moby/api/types/container"
+)
+
+// stackDrift is how far a project's containers are from its stack file.
+type stackDrift struct {
+	file    string
+	changes int
+	// orphans are the changes only stack up -remove-orphans applies.
+	orphans int
+	err     error
+}
+
+type driftMsg map[string]stackDrift
+
+// Steps of a stack start or stop come through a channel, like upload
+// progress, and end up in the status line.
+type stackStepMsg string
+type stackDoneMsg struct {
+	project string
+	start   bool
+	err     error
+}
+
+func projectOf(c container.Summary) string {
+	return c.Labels[stack.LabelProject]
+}
+
+// groupContainers orders containers by project, standalone ones last, for
+// the grouped list. The sort is stable so the daemon's order is kept within
+// a group.
+func groupContainers(containers []container.Summary) []container.Summary {
+	out := append([]container.Summary(nil), containers...)
+	sort.SliceStable(out, func(i, j int) bool {
+		pi, pj := projectOf(out[i]), projectOf(out[j])
+		if (pi == "") != (pj == "") {
+			return pi != ""
+		}
+		return pi < pj
+	})
+	return out
+}
+
+// refreshDrift recomputes drift for the grouped list, nil when the list
+// isn't grouped.
+func (m model) refreshDrift() tea.Cmd {
+	if !m.grouped {
+		return nil
+	}
+	containers := m.containers
+	inspect := stack.Inspector(context.Background(), m.client)
+	return func() tea.Msg {
+		return driftMsg(computeDrift(containers, inspect))
+	}
+}
+
+// computeDrift compares every project created by stackr with the stack
+// file recorded on its containers.
+func computeDrift(containers []container.Summary, inspect stack.InspectFunc) map[string]stackDrift {
+	files := map[string]string{}
+	for _, c := range containers {
+		p := projectOf(c)
+		if p == "" || c.Labels[stack.LabelConfigHash] == "" {
+			continue
+		}
+		if f := c.Labels[stack.LabelConfigFiles]; f != "" {
+			files[p] = f
+		}
+	}
+
+	drift := map[string]stackDrift{}
+	for project, file := range files {
+		d := stackDrift{file: file}
+		st, err := stack.Load(file)
+		if err == nil && st.Name != project {
+			err = fmt.Errorf("%s now defines stack %s", file, st.Name)
+		}
+		if err != nil {
+			d.err = err
+			drift[project] = d
+			continue
+		}
+		changes, err := stack.Diff(st, containers, inspect)
+		d.changes, d.err = stack.Drifted(changes), err
+		for _, ch := range changes {
+			if ch.Action == stack.ActionRemove {
+				d.orphans++
+			}
+		}
+		drift[project] = d
+	}
+	return drift
+}
+
+// listRows renders the list lines, with a header above each project when
+// grouped, and returns the row of the cursor.
+func (m model) listRows(items []container.Summary) ([]string, int) {
+	var rows []string
+	cursorRow := 0
+	for i, c := range items {
+		if m.grouped {
+			if p := projectOf(c); i == 0 || p != projectOf(items[i-1]) {
+				rows = append(rows, m.renderGroupHeader(p, items))
+			}
+		}
+		if i == m.cursor {
+			cursorRow = len(rows)
+		}
+		rows = append(rows, m.renderLine(c, i == m.cursor))
+	}
+	return rows, cursorRow
+}
+
+func (m model) renderGroupHeader(project string, items []container.Summary) string {
+	if project == "" {
+		return boxTitleStyle.Render("▾ standalone")
+	}
+	total, running := 0, 0
+	for _, c := range items {
+		if projectOf(c) != project {
+			continue
+		}
+		total++
+		if c.State == container.StateRunning {
+			running++
+		}
+	}
+	header := boxTitleStyle.Render("▾ "+project) + statusStyle.Render(fmt.Sprintf("  %d/%d running", running, total))
+
+	d, ok := m.drift[project]
+	switch {
+	case !ok:
+	case d.err != nil:
+		header += warningStyle.Render("  ⚠ " + d.err.Error())
+	case d.changes > 0:
+		up := "stackr stack up"
+		if d.orphans > 0 {
+			up += " -remove-orphans"
+		}
+		header += warningStyle.Render(fmt.Sprintf("  ⚠ drifted, %d changes pending (%s -f %s)", d.changes, up, d.file))
+	default:
+		header += runningStyle.Render("  ✓ in sync")
+	}
+	return header
+}
+
+// updateStackAction follows a running stack start or stop.
+func (m model) updateStackAction(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case stackStepMsg:
+		m.status = string(msg)
+		return m, m.waitStackSteps()
+	case stackDoneMsg:
+		m.stackSteps = nil
+		switch {
+		case msg.err != nil:
+			m.status = "Error: " + msg.err.Error()
+		case msg.start:
+			m.status = "Stack " + msg.project + " started"
+		default:
+			m.status = "Stack " + msg.project + " stopped"
+		}
+		return m, m.fetchContainers
+	}
+	return m, nil
+}
+
+// startStackAction starts or stops the whole project of the selected
+// container in dependency order.
+func (m model) startStackAction(start bool) (tea.Model, tea.Cmd) {
+	project := ""
+	for _, c := range m.containers {
+		if c.ID == m.selectedID {
+			project = projectOf(c)
+		}
+	}
+	switch {
+	case project == "":
+		m.status = "Not part of a stack or Compose project."
+		return m, nil
+	case m.stackSteps != nil:
+		m.status = "Wait for the running stack action to finish."
+		return m, nil
+	}
+	if start {
+		m.status = "Starting stack " + project + " in dependency order..."
+	} else {
+		m.status = "Stopping stack " + project + ", dependents first..."
+	}
+	m.stackSteps = make(chan tea.Msg, 1)
+	return m, tea.Batch(m.runStackAction(project, start), m.waitStackSteps())
+}
+
+func (m model) runStackAction(project string, start bool) tea.Cmd {
+	client := m.client
+	byService := stack.Containers(project, m.containers)
+	ch := m.stackSteps
+	return func() tea.Msg {
+		progress := func(step string) {
+			// Drop a step rather than block if the UI is behind.
+			select {
+			case ch <- stackStepMsg(step):
+			default:
+			}
+		}
+		st := projectStack(project, byService)
+		var err error
+		if start {
+			err = stack.StartProject(context.Background(), client, byService, st, progress)
+		} else {
+			err = stack.StopProject(context.Background(), client, byService, st, progress)
+		}
+		ch <- stackDoneMsg{project: project, start: start, err: err}
+		close(ch)
+		return nil
+	}
+}
+
+func (m model) waitStackSteps() tea.Cmd {
+	ch := m.stackSteps
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// projectStack loads the file a project was started from, so its
+// depends_on wins over the labels, which may be stale. nil when there is
+// no such file or it no longer defines the project.
+func projectStack(project string, byService map[string]container.Summary) *stack.Stack {
+	for _, c := range byService {
+		file, _, _ := strings.Cut(c.Labels[stack.LabelConfigFiles], ",")
+		if file == "" {
+			continue
+		}
+		st, err := stack.Load(file)
+		if err != nil || st.Name != project {
+			return nil
+		}
+		return st
+	}
+	return nil
+}
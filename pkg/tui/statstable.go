@@ -0,0 +1,354 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/stats"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/moby/moby/api/types/container"
+)
+
+const (
+	// statsTableInterval is the pause between two sampling rounds.
+	statsTableInterval = 2 * time.Second
+	// statsTableConcurrency bounds the stats calls in flight.
+	statsTableConcurrency = 8
+)
+
+// statsTable is the state of the stats view: the latest reading of every
+// running container, sampled in the background until cancel is called.
+// Readings are one-shot, CPU usage is computed against the previous round.
+type statsTable struct {
+	rows     map[string]statsRow
+	selected string
+	sortBy   int
+	reverse  bool
+	msgs     chan tea.Msg
+	cancel   context.CancelFunc
+	sampler  *stats.Sampler
+}
+
+type statsRow struct {
+	id, name string
+	stats    *container.StatsResponse
+	err      error
+}
+
+// statsRoundMsg starts a sampling round with the containers running at
+// that time; statsSampleMsg is one reading within it.
+type statsRoundMsg struct {
+	containers []container.Summary
+	err        error
+}
+type statsSampleMsg struct {
+	id    string
+	stats *container.StatsResponse
+	err   error
+}
+
+// statsColumns are the table's columns. value orders rows when sorting by
+// the column; the name column sorts by name instead.
+var statsColumns = []struct {
+	title string
+	width int
+	value func(r statsRow) float64
+}{
+	{"NAME", 24, nil},
+	{"CPU %", 8, func(r statsRow) float64 { return stats.CPUPercent(r.stats) }},
+	{"MEM USAGE / LIMIT", 22, func(r statsRow) float64 { return float64(stats.MemoryUsage(r.stats)) }},
+	{"MEM %", 7, func(r statsRow) float64 { return stats.MemoryPercent(r.stats) }},
+	{"NET I/O", 22, func(r statsRow) float64 { rx, tx := stats.NetworkIO(r.stats); return float64(rx + tx) }},
+	{"BLOCK I/O", 22, func(r statsRow) float64 { rd, wr := stats.BlockIO(r.stats); return float64(rd + wr) }},
+	{"PIDS", 6, func(r statsRow) float64 { return float64(r.stats.PidsStats.Current) }},
+}
+
+func (m model) enterStatsTable() (model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.view = viewStats
+	m.status = "Sampling running containers..."
+	m.statsTable = statsTable{
+		rows:     map[string]statsRow{},
+		selected: m.selectedID,
+		sortBy:   1,
+		msgs:     make(chan tea.Msg, statsTableConcurrency),
+		cancel:   cancel,
+		sampler:  stats.NewSampler(),
+	}
+	return m, tea.Batch(m.sampleStats(ctx), m.waitStats())
+}
+
+// leaveStatsTable stops the sampling. Readings still in flight are
+// dropped with the channel.
+func (m model) leaveStatsTable() model {
+	if m.statsTable.cancel != nil {
+		m.statsTable.cancel()
+	}
+	m.statsTable.msgs = nil
+	m.status = ""
+	return m
+}
+
+func (m model) updateStatsTable(msg tea.Msg) (tea.Model, tea.Cmd) {
+	t := &m.statsTable
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		rows := t.sorted()
+		cursor := t.cursor(rows)
+		switch {
+		case key.Matches(msg, keys.Back):
+			m = m.leaveStatsTable()
+			m.view = viewList
+			return m, m.fetchContainers
+		case key.Matches(msg, keys.Up):
+			if cursor > 0 {
+				t.selected = rows[cursor-1].id
+			}
+		case key.Matches(msg, keys.Down):
+			if cursor < len(rows)-1 {
+				t.selected = rows[cursor+1].id
+			}
+		case key.Matches(msg, keys.Left):
+			t.sortBy = (t.sortBy + len(statsColumns) - 1) % len(statsColumns)
+		case key.Matches(msg, keys.Right):
+			t.sortBy = (t.sortBy + 1) % len(statsColumns)
+		case key.Matches(msg, keys.Toggle):
+			t.reverse = !t.reverse
+		case key.Matches(msg, keys.Enter):
+			if cursor < 0 {
+				return m, nil
+			}
+			m = m.leaveStatsTable()
+			m.selectedID = rows[cursor].id
+			m, cmd := m.enterDetail()
+			return m, tea.Batch(m.fetchContainers, cmd)
+		}
+
+	case statsRoundMsg:
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+			return m, m.waitStats()
+		}
+		m.status = ""
+		running := map[string]bool{}
+		for _, c := range msg.containers {
+			running[c.ID] = true
+			if _, ok := t.rows[c.ID]; !ok {
+				t.rows[c.ID] = statsRow{id: c.ID, name: containerName(c)}
+			}
+		}
+		for id := range t.rows {
+			if !running[id] {
+				delete(t.rows, id)
+			}
+		}
+		return m, m.waitStats()
+
+	case statsSampleMsg:
+		if row, ok := t.rows[msg.id]; ok {
+			row.stats, row.err = msg.stats, msg.err
+			t.rows[msg.id] = row
+		}
+		return m, m.waitStats()
+	}
+	return m, nil
+}
+
+// sampleStats reads the stats of every running container, at most
+// statsTableConcurrency at a time, round after round until ctx is
+// cancelled. Each reading is sent as soon as it is in.
+func (m model) sampleStats(ctx context.Context) tea.Cmd {
+	ch, sampler := m.statsTable.msgs, m.statsTable.sampler
+	return func() tea.Msg {
+		defer close(ch)
+		send := func(msg tea.Msg) {
+			select {
+			case ch <- msg:
+			case <-ctx.Done():
+			}
+		}
+		sem := make(chan struct{}, statsTableConcurrency)
+		for {
+			containers, err := m.client.ListContainers(ctx)
+			var running []container.Summary
+			for _, c := range containers {
+				if c.State == container.StateRunning {
+					running = append(running, c)
+				}
+			}
+			send(statsRoundMsg{containers: running, err: err})
+
+			var wg sync.WaitGroup
+			for _, c := range running {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					select {
+					case sem <- struct{}{}:
+					case <-ctx.Done():
+						return
+					}
+					defer func() { <-sem }()
+					s, err := m.client.Stats(ctx, c.ID)
+					if ctx.Err() != nil {
+						return
+					}
+					if err == nil {
+						sampler.Prime(c.ID, s)
+					}
+					send(statsSampleMsg{id: c.ID, stats: s, err: err})
+				}()
+			}
+			wg.Wait()
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(statsTableInterval):
+			}
+		}
+	}
+}
+
+func (m model) waitStats() tea.Cmd {
+	ch := m.statsTable.msgs
+	return func() tea.Msg {
+		if ch == nil {
+			return nil
+		}
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// sorted returns the rows in display order: by name A to Z, or biggest
+// first for the other columns, unless reversed. Rows without a reading yet
+// go last.
+func (t statsTable) sorted() []statsRow {
+	rows := make([]statsRow, 0, len(t.rows))
+	for _, r := range t.rows {
+		rows = append(rows, r)
+	}
+	value := statsColumns[t.sortBy].value
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if value != nil && (a.stats == nil) != (b.stats == nil) {
+			return a.stats != nil
+		}
+		var before bool
+		if value == nil || a.stats == nil {
+			if a.name == b.name {
+				return a.id < b.id
+			}
+			before = a.name < b.name
+		} else {
+			va, vb := value(a), value(b)
+			if va == vb {
+				return a.name < b.name
+			}
+			before = va > vb
+		}
+		return before != t.reverse
+	})
+	return rows
+}
+
+// cursor is the index of the selected row, the first one if it is gone,
+// -1 when there are none.
+func (t statsTable) cursor(rows []statsRow) int {
+	for i, r := range rows {
+		if r.id == t.selected {
+			return i
+		}
+	}
+	if len(rows) == 0 {
+		return -1
+	}
+	return 0
+}
+
+func (m model) viewStatsTable() string {
+	var b strings.Builder
+	t := m.statsTable
+	rows := t.sorted()
+	cursor := t.cursor(rows)
+
+	b.WriteString(titleStyle.Render("⬡ STATS"))
+	b.WriteString(statusStyle.Render(fmt.Sprintf("  %d running, every %s", len(rows), statsTableInterval)))
+	b.WriteString("\n\n")
+
+	var header []string
+	for i, col := range statsColumns {
+		title := col.title
+		if i == t.sortBy {
+			if (col.value == nil) != t.reverse {
+				title += " ▲"
+			} else {
+				title += " ▼"
+			}
+		}
+		header = append(header, fmt.Sprintf("%-*s", col.width, title))
+	}
+	b.WriteString(boxTitleStyle.Render("  "+strings.Join(header, " ")) + "\n")
+
+	visible := max(m.height-7, 5)
+	offset := max(cursor-visible+1, 0)
+	end := min(offset+visible, len(rows))
+	for i := offset; i < end; i++ {
+		line := renderStatsRow(rows[i])
+		if i == cursor {
+			b.WriteString(selectedStyle.Render("▸ "+line) + "\n")
+		} else {
+			b.WriteString(valueStyle.Render("  "+line) + "\n")
+		}
+	}
+	if len(rows) == 0 && m.status == "" {
+		b.WriteString(statusStyle.Render("  No running containers.\n"))
+	}
+
+	if m.status != "" {
+		b.WriteString("\n" + statusStyle.Render("  "+m.status) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("[↑↓] select  [←→] sort column  [tab] reverse  [enter] details  [esc]back  [q]uit"))
+	return b.String()
+}
+
+func renderStatsRow(r statsRow) string {
+	cells := []string{format.Truncate(r.name, statsColumns[0].width)}
+	switch {
+	case r.err != nil:
+		cells = append(cells, format.Truncate(r.err.Error(), 60))
+	case r.stats == nil:
+		cells = append(cells, "--")
+	default:
+		s := r.stats
+		rx, tx := stats.NetworkIO(s)
+		read, write := stats.BlockIO(s)
+		cpu := "--"
+		if stats.HasPrevious(s) {
+			cpu = fmt.Sprintf("%.1f%%", stats.CPUPercent(s))
+		}
+		cells = append(cells,
+			cpu,
+			format.Bytes(stats.MemoryUsage(s))+" / "+format.Bytes(s.MemoryStats.Limit),
+			fmt.Sprintf("%.1f%%", stats.MemoryPercent(s)),
+			format.Bytes(rx)+" / "+format.Bytes(tx),
+			format.Bytes(read)+" / "+format.Bytes(write),
+			fmt.Sprintf("%d", s.PidsStats.Current),
+		)
+	}
+	for i := range cells {
+		cells[i] = fmt.Sprintf("%-*s", statsColumns[i].width, cells[i])
+	}
+	return strings.TrimRight(strings.Join(cells, " "), " ")
+}
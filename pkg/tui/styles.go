@@ -29,6 +29,9 @@ var (
 	stoppedStyle = lipgloss.NewStyle().
 			Foreground(errorColor)
 
+	warningStyle = lipgloss.NewStyle().
+			Foreground(warningColor)
+
 	// Status indicators
 	runningDot = lipgloss.NewStyle().Foreground(successColor).Render("●")
 	stoppedDot = lipgloss.NewStyle().Foreground(errorColor).Render("○")
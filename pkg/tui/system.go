@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// systemDash is the state of the system view. cursor picks a disk usage
+// category, confirm is set while a prune of it awaits confirmation.
+type systemDash struct {
+	info    *docker.SystemInfo
+	cursor  int
+	confirm bool
+	busy    bool
+}
+
+type systemMsg struct {
+	info docker.SystemInfo
+	err  error
+}
+type pruneDoneMsg struct {
+	result docker.PruneResult
+	err    error
+}
+
+// pruneDescriptions say what a prune of each kind removes.
+var pruneDescriptions = map[docker.PruneKind]string{
+	docker.PruneContainers: "all stopped containers",
+	docker.PruneImages:     "dangling images",
+	docker.PruneVolumes:    "anonymous volumes not used by any container",
+	docker.PruneNetworks:   "networks not used by any container",
+	docker.PruneBuildCache: "dangling build cache",
+}
+
+func (m model) enterSystem() (model, tea.Cmd) {
+	m.view = viewSystem
+	m.status = "Loading system information..."
+	m.system = systemDash{busy: true}
+	return m, m.loadSystem
+}
+
+func (m model) updateSystem(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.system.confirm {
+			switch {
+			case key.Matches(msg, keys.Confirm):
+				kind := docker.PruneKinds[m.system.cursor]
+				m.system.confirm = false
+				m.system.busy = true
+				m.status = "Pruning " + pruneDescriptions[kind] + "..."
+				return m, m.pruneKind(kind)
+			case key.Matches(msg, keys.Cancel):
+				m.system.confirm = false
+				m.status = ""
+			}
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewList
+			m.status = ""
+			return m, m.fetchContainers
+		case key.Matches(msg, keys.Up):
+			if m.system.cursor > 0 {
+				m.system.cursor--
+			}
+		case key.Matches(msg, keys.Down):
+			if m.system.cursor < len(docker.PruneKinds)-1 {
+				m.system.cursor++
+			}
+		case key.Matches(msg, keys.Wizard):
+			return m.enterPrune()
+		case key.Matches(msg, keys.Refresh):
+			m.system.busy = true
+			m.status = "Loading system information..."
+			return m, m.loadSystem
+		case key.Matches(msg, keys.Prune):
+			if m.system.busy {
+				return m, nil
+			}
+			kind := docker.PruneKinds[m.system.cursor]
+			m.system.confirm = true
+			m.status = fmt.Sprintf("Remove %s? [y] yes  [n] no", pruneDescriptions[kind])
+			if m.system.info == nil {
+				return m, nil
+			}
+			if size, ok := m.system.info.PruneEstimate(kind); ok {
+				m.status = fmt.Sprintf("Remove %s, freeing up to %s? [y] yes  [n] no",
+					pruneDescriptions[kind], format.Bytes(uint64(size)))
+			}
+		}
+
+	case systemMsg:
+		m.system.busy = false
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+			return m, nil
+		}
+		m.system.info = &msg.info
+		m.status = ""
+
+	case pruneDoneMsg:
+		m.system.busy = false
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+		} else {
+			m.status = fmt.Sprintf("Removed %d %s, reclaimed %s", len(msg.result.Deleted), msg.result.Kind, format.Bytes(msg.result.Reclaimed))
+		}
+		return m, m.loadSystem
+	}
+	return m, nil
+}
+
+func (m model) viewSystem() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("⬡ SYSTEM"))
+
+	s := m.system.info
+	if s == nil {
+		b.WriteString("\n\n")
+		if m.status != "" {
+			b.WriteString(statusStyle.Render("  " + m.status))
+		}
+		b.WriteString(helpStyle.Render("\n\n[esc]back  [q]uit"))
+		return b.String()
+	}
+
+	info, v := s.Info, s.Version
+	b.WriteString(statusStyle.Render(fmt.Sprintf("  Docker %s (API %s) %s/%s", v.Version, v.APIVersion, v.Os, v.Arch)))
+	b.WriteString("\n\n")
+
+	row := func(label, value string) {
+		b.WriteString("  " + labelStyle.Render(fmt.Sprintf("%-12s", label)) + valueStyle.Render(value) + "\n")
+	}
+	row("Host", fmt.Sprintf("%s, %s, kernel %s", info.Name, info.OperatingSystem, info.KernelVersion))
+	cgroup := "cgroup v" + info.CgroupVersion
+	if info.CgroupDriver != "" {
+		cgroup += " (" + info.CgroupDriver + ")"
+	}
+	row("Storage", fmt.Sprintf("%s in %s, %s", info.Driver, info.DockerRootDir, cgroup))
+	row("Resources", fmt.Sprintf("%d CPUs, %s memory", info.NCPU, format.Bytes(uint64(info.MemTotal))))
+	row("Containers", fmt.Sprintf("%d total, %s %d running  %s %d paused  %s %d stopped",
+		info.Containers, runningDot, info.ContainersRunning, pausedDot, info.ContainersPaused, stoppedDot, info.ContainersStopped))
+	row("Images", fmt.Sprintf("%d", info.Images))
+
+	b.WriteString("\n" + boxTitleStyle.Render(fmt.Sprintf("  %-14s %9s %12s %20s", "DISK USAGE", "ACTIVE", "SIZE", "RECLAIMABLE")) + "\n")
+	for i, kind := range docker.PruneKinds {
+		active, total, size := m.diskUsage(kind)
+		reclaim := m.reclaimable(kind)
+		usage := ""
+		if kind != docker.PruneNetworks {
+			pct := 0.0
+			if size > 0 {
+				pct = float64(reclaim) / float64(size) * 100
+			}
+			usage = fmt.Sprintf("%12s %20s", format.Bytes(uint64(size)), fmt.Sprintf("%s (%.0f%%)", format.Bytes(uint64(reclaim)), pct))
+		}
+		counts := ""
+		if total >= 0 {
+			counts = fmt.Sprintf("%d/%d", active, total)
+		}
+		line := fmt.Sprintf("%-14s %9s %s", string(kind), counts, usage)
+		if i == m.system.cursor {
+			b.WriteString(selectedStyle.Render("▸ "+line) + "\n")
+		} else {
+			b.WriteString(valueStyle.Render("  "+line) + "\n")
+		}
+	}
+	b.WriteString(statusStyle.Render("\n  [p] removes " + pruneDescriptions[docker.PruneKinds[m.system.cursor]]))
+
+	if m.status != "" {
+		b.WriteString("\n\n")
+		b.WriteString(statusStyle.Render("  " + m.status))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render("[↑↓] select  [p]rune  [W] prune wizard  [f]refresh  [esc]back  [q]uit"))
+	return b.String()
+}
+
+// diskUsage returns the active and total counts and the size of a
+// category. Networks take no disk space and have no counts from the
+// daemon, total is -1 for them.
+func (m model) diskUsage(kind docker.PruneKind) (active, total, size int64) {
+	d := m.system.info.Disk
+	switch kind {
+	case docker.PruneContainers:
+		return d.Containers.ActiveCount, d.Containers.TotalCount, d.Containers.TotalSize
+	case docker.PruneImages:
+		return d.Images.ActiveCount, d.Images.TotalCount, d.Images.TotalSize
+	case docker.PruneVolumes:
+		return d.Volumes.ActiveCount, d.Volumes.TotalCount, d.Volumes.TotalSize
+	case docker.PruneBuildCache:
+		return d.BuildCache.ActiveCount, d.BuildCache.TotalCount, d.BuildCache.TotalSize
+	}
+	return 0, -1, 0
+}
+
+func (m model) reclaimable(kind docker.PruneKind) int64 {
+	if m.system.info == nil {
+		return 0
+	}
+	d := m.system.info.Disk
+	switch kind {
+	case docker.PruneContainers:
+		return d.Containers.Reclaimable
+	case docker.PruneImages:
+		return d.Images.Reclaimable
+	case docker.PruneVolumes:
+		return d.Volumes.Reclaimable
+	case docker.PruneBuildCache:
+		return d.BuildCache.Reclaimable
+	}
+	return 0
+}
+
+func (m model) loadSystem() tea.Msg {
+	info, err := m.client.SystemInfo(context.Background())
+	return systemMsg{info: info, err: err}
+}
+
+func (m model) pruneKind(kind docker.PruneKind) tea.Cmd {
+	return func() tea.Msg {
+		res, err := m.client.Prune(context.Background(), kind)
+		return pruneDoneMsg{result: res, err: err}
+	}
+}
@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/moby/moby/api/types/container"
+)
+
+// topInterval is how often the process list refreshes in the detail view.
+const topInterval = 2 * time.Second
+
+// topMsg and topTickMsg carry the sequence number of the detail session
+// that scheduled them, so a loop started for a previous container dies out
+// instead of running alongside the new one.
+type topMsg struct {
+	seq int
+	top *container.TopResponse
+}
+type topTickMsg struct {
+	seq int
+}
+type signalSentMsg struct {
+	signal string
+}
+
+// enterDetail switches to the detail view of the selected container and
+// starts its refresh loop.
+func (m model) enterDetail() (model, tea.Cmd) {
+	m.view = viewDetail
+	m.status = ""
+	m.top = nil
+	if m.inspect != nil && m.inspect.ID != m.selectedID {
+		m.inspect = nil
+	}
+	m.topSeq++
+	return m, tea.Batch(m.fetchContainerDetail, m.fetchTop(m.topSeq))
+}
+
+func (m model) updateTop(msg tea.Msg) (model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case topMsg:
+		if msg.seq != m.topSeq || m.view == viewList {
+			return m, nil
+		}
+		m.top = msg.top
+		if m.view == viewDetail && m.inspect != nil {
+			m.viewport.SetContent(m.renderDetailContent())
+		}
+		seq := msg.seq
+		return m, tea.Tick(topInterval, func(time.Time) tea.Msg { return topTickMsg{seq: seq} })
+	case topTickMsg:
+		if msg.seq != m.topSeq || m.view == viewList {
+			return m, nil
+		}
+		return m, m.fetchTop(msg.seq)
+	}
+	return m, nil
+}
+
+func (m model) fetchTop(seq int) tea.Cmd {
+	id := m.selectedID
+	return func() tea.Msg {
+		if id == "" {
+			return nil
+		}
+		top, err := m.client.Top(context.Background(), id)
+		if err != nil {
+			// Not running or ps unavailable, the box is simply hidden.
+			return topMsg{seq: seq}
+		}
+		return topMsg{seq: seq, top: &top}
+	}
+}
+
+func (m model) renderProcessesBox(width int) string {
+	var content strings.Builder
+	content.WriteString(boxTitleStyle.Render("PROCESSES"))
+	content.WriteString("\n\n")
+
+	cols := []struct {
+		titles []string
+		label  string
+		width  int
+	}{
+		{[]string{"PID"}, "PID", 7},
+		{[]string{"USER", "UID"}, "USER", 10},
+		{[]string{"%CPU"}, "CPU%", 6},
+		{[]string{"%MEM"}, "MEM%", 6},
+		{[]string{"COMMAND", "CMD"}, "COMMAND", 0},
+	}
+
+	idx := make([]int, len(cols))
+	for i, col := range cols {
+		idx[i] = -1
+		for j, t := range m.top.Titles {
+			for _, want := range col.titles {
+				if t == want && idx[i] < 0 {
+					idx[i] = j
+				}
+			}
+		}
+	}
+
+	var header []string
+	for _, col := range cols {
+		header = append(header, fmt.Sprintf("%-*s", col.width, col.label))
+	}
+	content.WriteString(labelStyle.Render(strings.Join(header, " ")))
+
+	cmdWidth := width - 4
+	for _, col := range cols[:len(cols)-1] {
+		cmdWidth -= col.width + 1
+	}
+
+	for _, proc := range m.top.Processes {
+		var cells []string
+		for i, col := range cols {
+			cell := ""
+			if idx[i] >= 0 && idx[i] < len(proc) {
+				cell = proc[idx[i]]
+			}
+			if col.width == 0 {
+				cells = append(cells, format.Truncate(cell, cmdWidth))
+			} else {
+				cells = append(cells, fmt.Sprintf("%-*s", col.width, format.Truncate(cell, col.width)))
+			}
+		}
+		content.WriteString("\n")
+		content.WriteString(valueStyle.Render(strings.Join(cells, " ")))
+	}
+
+	return boxStyle.Width(width).Render(content.String())
+}
+
+func newSignalForm(name string) form {
+	f := newForm("⬡ SIGNAL "+name, "Signal")
+	f.setValue(0, "SIGTERM")
+	f.setPlaceholder(0, "SIGTERM, SIGHUP, SIGUSR1, 9...")
+	return f
+}
+
+func (m model) updateSignal(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			return m, nil
+		case key.Matches(msg, keys.Submit):
+			signal := m.form.value(0)
+			if signal == "" {
+				m.form.err = fmt.Errorf("signal is required")
+				return m, nil
+			}
+			return m, m.sendSignal(m.detailID(), signal)
+		}
+	case signalSentMsg:
+		m.view = viewDetail
+		m.status = "Sent " + msg.signal
+		return m, m.fetchContainerDetail
+	case formErrMsg:
+		m.form.err = msg
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.form, cmd = m.form.update(msg)
+	return m, cmd
+}
+
+func (m model) viewSignal() string {
+	var b strings.Builder
+
+	b.WriteString(m.form.view(m.width))
+
+	help := "[ctrl+s] send  [esc] cancel  [ctrl+c] quit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) sendSignal(id, signal string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.client.Kill(context.Background(), id, signal); err != nil {
+			return formErrMsg(err)
+		}
+		return signalSentMsg{signal: signal}
+	}
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	updateMemory = iota
+	updateMemorySwap
+	updateCPUs
+	updateCPUShares
+	updatePidsLimit
+	updateRestart
+)
+
+type updatedMsg struct {
+	warnings []string
+}
+
+func newUpdateForm(name string, spec docker.UpdateSpec) form {
+	f := newForm("⬡ LIMITS "+name,
+		"Memory", "Memory+swap", "CPUs", "CPU shares", "PIDs limit", "Restart")
+	f.setValue(updateMemory, spec.Memory)
+	f.setValue(updateMemorySwap, spec.MemorySwap)
+	f.setValue(updateCPUs, spec.CPUs)
+	f.setValue(updateCPUShares, spec.CPUShares)
+	f.setValue(updatePidsLimit, spec.PidsLimit)
+	f.setValue(updateRestart, spec.Restart)
+	f.setPlaceholder(updateMemory, "unchanged, or unlimited")
+	f.setPlaceholder(updateMemorySwap, "unchanged, or unlimited")
+	f.setPlaceholder(updateCPUs, "unchanged, or unlimited")
+	f.setPlaceholder(updateCPUShares, "unchanged")
+	f.setPlaceholder(updatePidsLimit, "unchanged, or unlimited")
+	f.setPlaceholder(updateRestart, "unchanged")
+	return f
+}
+
+// updateSpec reads the form. Swap is only sent once edited: the current
+// value is shown, and docker.Update scales it along when memory changes
+// alone, where sending it back would keep memory from being raised above
+// it.
+func (m model) updateSpec() docker.UpdateSpec {
+	spec := docker.UpdateSpec{
+		Memory:     m.form.value(updateMemory),
+		MemorySwap: m.form.value(updateMemorySwap),
+		CPUs:       m.form.value(updateCPUs),
+		CPUShares:  m.form.value(updateCPUShares),
+		PidsLimit:  m.form.value(updatePidsLimit),
+		Restart:    m.form.value(updateRestart),
+	}
+	if m.inspect != nil && spec.MemorySwap == docker.UpdateSpecFromHostConfig(m.inspect.HostConfig).MemorySwap {
+		spec.MemorySwap = ""
+	}
+	return spec
+}
+
+func (m model) updateUpdate(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewDetail
+			return m, nil
+		case key.Matches(msg, keys.Submit):
+			spec := m.updateSpec()
+			if err := spec.Validate(); err != nil {
+				m.form.err = err
+				return m, nil
+			}
+			m.form.err = nil
+			return m, m.updateContainer(m.detailID(), spec)
+		}
+	case updatedMsg:
+		m.view = viewDetail
+		m.status = strings.Join(msg.warnings, "; ")
+		return m, m.fetchContainerDetail
+	case formErrMsg:
+		m.form.err = msg
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.form, cmd = m.form.update(msg)
+	return m, cmd
+}
+
+func (m model) viewUpdate() string {
+	var b strings.Builder
+
+	b.WriteString(m.form.view(m.width))
+
+	help := "[tab/↑↓] field  [ctrl+s] apply  [esc] cancel  [ctrl+c] quit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) updateContainer(id string, spec docker.UpdateSpec) tea.Cmd {
+	return func() tea.Msg {
+		warnings, err := m.client.Update(context.Background(), id, spec)
+		if err != nil {
+			return formErrMsg(err)
+		}
+		return updatedMsg{warnings: warnings}
+	}
+}
@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	uploadSource = iota
+	uploadDest
+)
+
+// upload tracks a copy to the container. Progress is reported through a
+// channel that the update loop keeps draining until it is closed.
+type upload struct {
+	confirm  bool // destination exists and the user was asked to confirm
+	progress chan tea.Msg
+	sent     int64
+	total    int64
+}
+
+type uploadTargetMsg struct {
+	dir, name string
+	exists    bool
+}
+type uploadProgressMsg struct {
+	sent, total int64
+}
+type uploadDoneMsg struct {
+	target string
+	err    error
+}
+
+func newUploadForm(dest string) form {
+	f := newForm("⬡ UPLOAD", "Host path", "Destination")
+	f.setPlaceholder(uploadSource, "./config.yml")
+	f.setValue(uploadDest, dest)
+	return f
+}
+
+func (m model) updateUpload(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.upload.progress != nil {
+			// Transfer running, nothing to edit.
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = viewFiles
+			return m, nil
+		case key.Matches(msg, keys.Submit):
+			src, dest := m.form.value(uploadSource), m.form.value(uploadDest)
+			if _, err := os.Stat(src); err != nil {
+				m.form.err = err
+				return m, nil
+			}
+			if !path.IsAbs(dest) {
+				m.form.err = fmt.Errorf("destination must be an absolute path")
+				return m, nil
+			}
+			m.form.err = nil
+			return m, m.resolveUpload(src, dest)
+		}
+		// Editing invalidates a pending overwrite confirmation.
+		m.upload.confirm = false
+
+	case uploadTargetMsg:
+		if msg.exists && !m.upload.confirm {
+			m.upload.confirm = true
+			m.form.err = fmt.Errorf("%s already exists, press ctrl+s again to overwrite", path.Join(msg.dir, msg.name))
+			return m, nil
+		}
+		m.upload = upload{progress: make(chan tea.Msg, 1)}
+		return m, tea.Batch(m.startUpload(m.form.value(uploadSource), msg.dir, msg.name), m.waitUpload())
+
+	case uploadProgressMsg:
+		m.upload.sent, m.upload.total = msg.sent, msg.total
+		return m, m.waitUpload()
+
+	case uploadDoneMsg:
+		m.upload = upload{}
+		if msg.err != nil {
+			m.form.err = msg.err
+			return m, nil
+		}
+		m.view = viewFiles
+		m.status = "Uploaded " + msg.target
+		return m, tea.Batch(m.listDir(m.files.dir), m.fetchChanges)
+
+	case formErrMsg:
+		m.form.err = msg
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.form, cmd = m.form.update(msg)
+	return m, cmd
+}
+
+func (m model) viewUpload() string {
+	var b strings.Builder
+
+	b.WriteString(m.form.view(m.width))
+
+	if m.upload.progress != nil {
+		percent := 0.0
+		if m.upload.total > 0 {
+			percent = float64(m.upload.sent) / float64(m.upload.total) * 100
+		}
+		bar := renderProgressBar(percent, max(m.width-30, 10))
+		b.WriteString(fmt.Sprintf("\n  %s  %5.1f%%  %s / %s\n",
+			bar, percent, format.Bytes(uint64(m.upload.sent)), format.Bytes(uint64(m.upload.total))))
+	}
+
+	help := "[tab/↑↓] field  [ctrl+s] upload  [esc] cancel  [ctrl+c] quit"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m model) resolveUpload(src, dest string) tea.Cmd {
+	id := m.detailID()
+	return func() tea.Msg {
+		dir, name, exists, err := m.client.UploadTarget(context.Background(), id, src, dest)
+		if err != nil {
+			return formErrMsg(err)
+		}
+		return uploadTargetMsg{dir: dir, name: name, exists: exists}
+	}
+}
+
+// startUpload runs the transfer and feeds progress into the channel. Only
+// the latest progress matters, so updates are dropped while the UI hasn't
+// consumed the previous one.
+func (m model) startUpload(src, dir, name string) tea.Cmd {
+	id := m.detailID()
+	ch := m.upload.progress
+	return func() tea.Msg {
+		err := m.client.Upload(context.Background(), id, src, dir, name, func(sent, total int64) {
+			select {
+			case ch <- uploadProgressMsg{sent: sent, total: total}:
+			default:
+			}
+		})
+		ch <- uploadDoneMsg{target: path.Join(dir, name), err: err}
+		close(ch)
+		return nil
+	}
+}
+
+func (m model) waitUpload() tea.Cmd {
+	ch := m.upload.progress
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/moby/moby/api/types/container"
+)
+
+// yankMenu lists what can be copied from a container. from is the view to
+// go back to once something is copied or the menu is closed.
+type yankMenu struct {
+	name   string
+	items  []yankItem
+	cursor int
+	from   viewState
+}
+
+type yankItem struct {
+	label string
+	value string
+}
+
+type yankInspectMsg struct {
+	inspect container.InspectResponse
+	err     error
+}
+
+// enterYank opens the menu on the selected container. The detail view
+// already has it inspected; from the list it is inspected first.
+func (m model) enterYank() (model, tea.Cmd) {
+	if m.selectedID == "" {
+		return m, nil
+	}
+	m.yank = yankMenu{from: m.view}
+	m.view = viewYank
+	if m.yank.from == viewDetail && m.inspect != nil {
+		m.yank.name, m.yank.items = yankItems(*m.inspect)
+		return m, nil
+	}
+	m.status = "Loading..."
+	id := m.selectedID
+	return m, func() tea.Msg {
+		ins, err := m.client.Inspect(context.Background(), id)
+		return yankInspectMsg{inspect: ins, err: err}
+	}
+}
+
+// yankItems are the ID, name, IP on each network, a docker exec command and
+// the value of each environment variable, in that order.
+func yankItems(ins container.InspectResponse) (string, []yankItem) {
+	name := strings.TrimPrefix(ins.Name, "/")
+	items := []yankItem{
+		{"ID", ins.ID},
+		{"Name", name},
+	}
+	if ins.NetworkSettings != nil {
+		var nets []string
+		for n := range ins.NetworkSettings.Networks {
+			nets = append(nets, n)
+		}
+		sort.Strings(nets)
+		for _, n := range nets {
+			if ep := ins.NetworkSettings.Networks[n]; ep != nil && ep.IPAddress.IsValid() {
+				items = append(items, yankItem{"IP " + n, ep.IPAddress.String()})
+			}
+		}
+	}
+	items = append(items, yankItem{"Exec command", execCommand(ins)})
+	if ins.Config != nil {
+		for _, env := range ins.Config.Env {
+			k, v, _ := strings.Cut(env, "=")
+			items = append(items, yankItem{"$" + k, v})
+		}
+	}
+	return name, items
+}
+
+// execCommand is a docker exec that opens a shell in the container, as the
+// user and in the directory it runs with. The daemon stackr talks to is
+// passed along when it was picked with DOCKER_HOST, so the command still
+// works pasted into a shell without it.
+func execCommand(ins container.InspectResponse) string {
+	args := []string{"docker"}
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		args = append(args, "-H", host)
+	}
+	args = append(args, "exec", "-it")
+	if ins.Config != nil {
+		if ins.Config.User != "" {
+			args = append(args, "-u", ins.Config.User)
+		}
+		if ins.Config.WorkingDir != "" {
+			args = append(args, "-w", ins.Config.WorkingDir)
+		}
+	}
+	args = append(args, strings.TrimPrefix(ins.Name, "/"), "sh")
+	return strings.Join(args, " ")
+}
+
+func (m model) updateYank(msg tea.Msg) (tea.Model, tea.Cmd) {
+	y := &m.yank
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Back):
+			m.view = y.from
+			m.status = ""
+			return m, nil
+		case key.Matches(msg, keys.Up):
+			if y.cursor > 0 {
+				y.cursor--
+			}
+		case key.Matches(msg, keys.Down):
+			if y.cursor < len(y.items)-1 {
+				y.cursor++
+			}
+		case key.Matches(msg, keys.Enter), key.Matches(msg, keys.Yank):
+			if len(y.items) == 0 {
+				return m, nil
+			}
+			item := y.items[y.cursor]
+			return m, copyText(item.label+" of "+y.name, item.value)
+		}
+
+	case yankInspectMsg:
+		if m.view != viewYank {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.view = y.from
+			m.status = "Error: " + msg.err.Error()
+			return m, nil
+		}
+		m.status = ""
+		y.name, y.items = yankItems(msg.inspect)
+
+	case copiedMsg:
+		m.view = y.from
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+		} else {
+			m.status = "Copied " + msg.what
+		}
+	}
+	return m, nil
+}
+
+func (m model) viewYank() string {
+	var b strings.Builder
+	y := m.yank
+	b.WriteString(titleStyle.Render("⬡ COPY " + y.name))
+	b.WriteString("\n\n")
+
+	// Keep the cursor on screen when there are many variables.
+	visible := max(m.height-6, 5)
+	offset := max(y.cursor-visible+1, 0)
+	end := min(offset+visible, len(y.items))
+	for i := offset; i < end; i++ {
+		item := y.items[i]
+		line := fmt.Sprintf("%-24s %s", format.Truncate(item.label, 24), format.Truncate(item.value, max(m.width-32, 20)))
+		if i == y.cursor {
+			b.WriteString(selectedStyle.Render("▸ "+line) + "\n")
+		} else {
+			b.WriteString(valueStyle.Render("  "+line) + "\n")
+		}
+	}
+
+	if m.status != "" {
+		b.WriteString("\n" + statusStyle.Render("  "+m.status) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("[↑↓] select  [enter/y] copy  [esc]back  [q]uit"))
+	return b.String()
+}
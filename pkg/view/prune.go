@@ -0,0 +1,69 @@
+package view
+
+import (
+	"github.com/aogirikarma/mini-stackr-cli/pkg/docker"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+)
+
+// PruneItem is an object a prune removes, or would remove on a dry run.
+type PruneItem struct {
+	Kind string `json:"kind" yaml:"kind"`
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	Size int64  `json:"size" yaml:"size"`
+	// Result is "planned" on a dry run, then "removed" or "failed".
+	Result string `json:"result" yaml:"result"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func FromPruneItem(item docker.PruneItem, executed bool) PruneItem {
+	p := PruneItem{
+		Kind:   string(item.Kind),
+		ID:     item.ID,
+		Name:   item.Name,
+		Size:   item.Size,
+		Result: "planned",
+	}
+	switch {
+	case !executed:
+	case item.Err != nil:
+		p.Result, p.Error = "failed", item.Err.Error()
+	default:
+		p.Result = "removed"
+	}
+	return p
+}
+
+func (PruneItem) header(wide bool) []string {
+	h := []string{"KIND", "NAME", "SIZE", "RESULT"}
+	if wide {
+		h = append(h, "ID", "ERROR")
+	}
+	return h
+}
+
+func (p PruneItem) row(wide bool) []string {
+	name := p.Name
+	if name == "" {
+		name = shortID(p.ID)
+	}
+	r := []string{p.Kind, format.Truncate(name, 40), format.Bytes(uint64(p.Size)), p.Result}
+	if wide {
+		r = append(r, shortID(p.ID), p.Error)
+	} else if p.Error != "" {
+		r[3] += ": " + p.Error
+	}
+	return r
+}
+
+// shortID shortens hex IDs, "sha256:" prefix included, and leaves names
+// alone.
+func shortID(id string) string {
+	if len(id) > 7 && id[:7] == "sha256:" {
+		id = id[7:]
+	}
+	if len(id) == 64 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,67 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/aogirikarma/mini-stackr-cli/pkg/stack"
+)
+
+// StackService is a service of a stack next to the container running it.
+type StackService struct {
+	Stack     string `json:"stack" yaml:"stack"`
+	Service   string `json:"service" yaml:"service"`
+	Container string `json:"container,omitempty" yaml:"container,omitempty"`
+	ID        string `json:"id,omitempty" yaml:"id,omitempty"`
+	Image     string `json:"image,omitempty" yaml:"image,omitempty"`
+	State     string `json:"state" yaml:"state"`
+	Status    string `json:"status,omitempty" yaml:"status,omitempty"`
+	// Action is what stackr stack up would do, "none" when in sync.
+	Action string `json:"action" yaml:"action"`
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+func FromChange(stackName string, ch stack.Change) StackService {
+	s := StackService{
+		Stack:   stackName,
+		Service: ch.Service,
+		State:   "missing",
+		Action:  string(ch.Action),
+		Reason:  ch.Reason,
+	}
+	if c := ch.Container; c != nil {
+		summary := FromSummary(*c)
+		s.Container = summary.Name
+		s.ID = summary.ID
+		s.Image = summary.Image
+		s.State = summary.State
+		s.Status = summary.Status
+	}
+	return s
+}
+
+func (StackService) header(wide bool) []string {
+	h := []string{"SERVICE", "CONTAINER", "STATE", "STATUS", "DRIFT"}
+	if wide {
+		h = append([]string{"STACK"}, h...)
+		h = append(h, "ID", "IMAGE")
+	}
+	return h
+}
+
+func (s StackService) row(wide bool) []string {
+	drift := ""
+	if s.Action != string(stack.ActionNone) {
+		drift = strings.TrimSpace(s.Action + ": " + s.Reason)
+	}
+	r := []string{s.Service, s.Container, s.State, format.ShortStatus(s.Status), drift}
+	if wide {
+		id := s.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		r = append([]string{s.Stack}, r...)
+		r = append(r, id, s.Image)
+	}
+	return r
+}
@@ -0,0 +1,221 @@
+// Package view is the stable model stackr prints containers as. It is
+// derived from the Docker API types but only changes with Version, so
+// scripts reading stackr's output don't break when those types do.
+package view
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aogirikarma/mini-stackr-cli/pkg/format"
+	"github.com/moby/moby/api/types/container"
+)
+
+// Version is written with every JSON and YAML document. Fields may be
+// added within a version; renaming or removing one requires a new version.
+const Version = "stackr/v1"
+
+const composeProjectLabel = "com.docker.compose.project"
+
+// Container is a container as listed.
+type Container struct {
+	ID       string            `json:"id" yaml:"id"`
+	Name     string            `json:"name" yaml:"name"`
+	Image    string            `json:"image" yaml:"image"`
+	Command  string            `json:"command" yaml:"command"`
+	Created  time.Time         `json:"created" yaml:"created"`
+	State    string            `json:"state" yaml:"state"`
+	Status   string            `json:"status" yaml:"status"`
+	Health   string            `json:"health,omitempty" yaml:"health,omitempty"`
+	Project  string            `json:"project,omitempty" yaml:"project,omitempty"`
+	Ports    []Port            `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Networks []string          `json:"networks,omitempty" yaml:"networks,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+type Port struct {
+	HostIP        string `json:"host_ip,omitempty" yaml:"host_ip,omitempty"`
+	HostPort      uint16 `json:"host_port,omitempty" yaml:"host_port,omitempty"`
+	ContainerPort uint16 `json:"container_port" yaml:"container_port"`
+	Protocol      string `json:"protocol" yaml:"protocol"`
+}
+
+// ShortID is the 12 character ID docker prints.
+func (c Container) ShortID() string {
+	if len(c.ID) > 12 {
+		return c.ID[:12]
+	}
+	return c.ID
+}
+
+// Detail is a container as inspected.
+type Detail struct {
+	Container     `yaml:",inline"`
+	RestartPolicy string    `json:"restart_policy,omitempty" yaml:"restart_policy,omitempty"`
+	RestartCount  int       `json:"restart_count" yaml:"restart_count"`
+	StartedAt     time.Time `json:"started_at,omitzero" yaml:"started_at,omitempty"`
+	FinishedAt    time.Time `json:"finished_at,omitzero" yaml:"finished_at,omitempty"`
+	ExitCode      int       `json:"exit_code" yaml:"exit_code"`
+	Memory        int64     `json:"memory_limit,omitempty" yaml:"memory_limit,omitempty"`
+	CPUs          float64   `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	Env           []string  `json:"env,omitempty" yaml:"env,omitempty"`
+	Mounts        []Mount   `json:"mounts,omitempty" yaml:"mounts,omitempty"`
+	IPs           []IP      `json:"ips,omitempty" yaml:"ips,omitempty"`
+}
+
+type Mount struct {
+	Type        string `json:"type" yaml:"type"`
+	Source      string `json:"source" yaml:"source"`
+	Destination string `json:"destination" yaml:"destination"`
+	ReadOnly    bool   `json:"read_only,omitempty" yaml:"read_only,omitempty"`
+}
+
+type IP struct {
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+}
+
+func FromSummary(s container.Summary) Container {
+	c := Container{
+		ID:      s.ID,
+		Image:   s.Image,
+		Command: s.Command,
+		Created: time.Unix(s.Created, 0),
+		State:   string(s.State),
+		Status:  s.Status,
+		Project: s.Labels[composeProjectLabel],
+		Labels:  s.Labels,
+	}
+	if len(s.Names) > 0 {
+		c.Name = strings.TrimPrefix(s.Names[0], "/")
+	}
+	if s.Health != nil && s.Health.Status != container.NoHealthcheck {
+		c.Health = string(s.Health.Status)
+	}
+	for _, p := range s.Ports {
+		port := Port{HostPort: p.PublicPort, ContainerPort: p.PrivatePort, Protocol: p.Type}
+		if p.IP.IsValid() {
+			port.HostIP = p.IP.String()
+		}
+		c.Ports = append(c.Ports, port)
+	}
+	if s.NetworkSettings != nil {
+		for name := range s.NetworkSettings.Networks {
+			c.Networks = append(c.Networks, name)
+		}
+		sort.Strings(c.Networks)
+	}
+	return c
+}
+
+func FromInspect(ins container.InspectResponse) Detail {
+	d := Detail{
+		Container: Container{
+			ID:   ins.ID,
+			Name: strings.TrimPrefix(ins.Name, "/"),
+		},
+		RestartCount: ins.RestartCount,
+	}
+	c := &d.Container
+	c.Created, _ = time.Parse(time.RFC3339Nano, ins.Created)
+
+	if ins.Config != nil {
+		c.Image = ins.Config.Image
+		c.Command = strings.Join(append(append([]string{}, ins.Config.Entrypoint...), ins.Config.Cmd...), " ")
+		c.Labels = ins.Config.Labels
+		c.Project = ins.Config.Labels[composeProjectLabel]
+		d.Env = ins.Config.Env
+	}
+	if st := ins.State; st != nil {
+		c.State = string(st.Status)
+		c.Status = string(st.Status)
+		if st.Health != nil {
+			c.Health = string(st.Health.Status)
+		}
+		d.ExitCode = st.ExitCode
+		d.StartedAt, _ = time.Parse(time.RFC3339Nano, st.StartedAt)
+		d.FinishedAt, _ = time.Parse(time.RFC3339Nano, st.FinishedAt)
+	}
+	if hc := ins.HostConfig; hc != nil {
+		d.RestartPolicy = string(hc.RestartPolicy.Name)
+		d.Memory = hc.Memory
+		d.CPUs = float64(hc.NanoCPUs) / 1e9
+	}
+	if ns := ins.NetworkSettings; ns != nil {
+		for port, bindings := range ns.Ports {
+			for _, b := range bindings {
+				p := Port{ContainerPort: port.Num(), Protocol: string(port.Proto())}
+				if b.HostIP.IsValid() {
+					p.HostIP = b.HostIP.String()
+				}
+				if n, err := strconv.ParseUint(b.HostPort, 10, 16); err == nil {
+					p.HostPort = uint16(n)
+				}
+				c.Ports = append(c.Ports, p)
+			}
+		}
+		sort.Slice(c.Ports, func(i, j int) bool { return c.Ports[i].ContainerPort < c.Ports[j].ContainerPort })
+		for name, n := range ns.Networks {
+			c.Networks = append(c.Networks, name)
+			if n != nil && n.IPAddress.IsValid() {
+				d.IPs = append(d.IPs, IP{Network: name, Address: n.IPAddress.String()})
+			}
+		}
+		sort.Strings(c.Networks)
+		sort.Slice(d.IPs, func(i, j int) bool { return d.IPs[i].Network < d.IPs[j].Network })
+	}
+	for _, m := range ins.Mounts {
+		d.Mounts = append(d.Mounts, Mount{
+			Type:        string(m.Type),
+			Source:      m.Source,
+			Destination: m.Destination,
+			ReadOnly:    !m.RW,
+		})
+	}
+	return d
+}
+
+// header and row lay a container out for the table, wide and csv formats.
+
+func (Container) header(wide bool) []string {
+	h := []string{"NAME", "IMAGE", "STATE", "STATUS", "HEALTH", "PORTS"}
+	if wide {
+		h = append([]string{"ID"}, h...)
+		h = append(h, "PROJECT", "NETWORKS", "CREATED", "COMMAND")
+	}
+	return h
+}
+
+func (c Container) row(wide bool) []string {
+	r := []string{c.Name, c.Image, c.State, format.ShortStatus(c.Status), c.Health, c.portsString()}
+	if wide {
+		r = append([]string{c.ShortID()}, r...)
+		r = append(r, c.Project, strings.Join(c.Networks, ","), c.Created.Local().Format("2006-01-02 15:04"), c.Command)
+	}
+	return r
+}
+
+func (d Detail) header(wide bool) []string {
+	return append(d.Container.header(wide), "RESTARTS", "EXIT")
+}
+
+func (d Detail) row(wide bool) []string {
+	return append(d.Container.row(wide), strconv.Itoa(d.RestartCount), strconv.Itoa(d.ExitCode))
+}
+
+func (c Container) portsString() string {
+	var parts []string
+	for _, p := range c.Ports {
+		s := strconv.Itoa(int(p.ContainerPort))
+		if p.HostPort != 0 {
+			s = strconv.Itoa(int(p.HostPort)) + ":" + s
+		}
+		if p.Protocol != "" && p.Protocol != "tcp" {
+			s += "/" + p.Protocol
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ",")
+}
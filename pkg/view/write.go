@@ -0,0 +1,101 @@
+package view
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FormatUsage describes the values accepted by Write, for flag help.
+const FormatUsage = "output format: table, wide, json, yaml, csv or a Go template such as '{{.Name}} {{.State}}'"
+
+// Document is the envelope of JSON and YAML output.
+type Document[T any] struct {
+	Version string `json:"version" yaml:"version"`
+	Kind    string `json:"kind" yaml:"kind"`
+	Items   []T    `json:"items" yaml:"items"`
+}
+
+// tabular is implemented by the types that have a table layout.
+type tabular interface {
+	header(wide bool) []string
+	row(wide bool) []string
+}
+
+// Write renders items in format. kind names the item type in JSON and
+// YAML documents. Anything containing "{{" is a Go template executed for
+// each item on its own line.
+func Write[T tabular](w io.Writer, format, kind string, items []T) error {
+	if strings.Contains(format, "{{") {
+		return writeTemplate(w, format, items)
+	}
+
+	switch format {
+	case "", "table", "wide":
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		wide := format == "wide"
+		var zero T
+		fmt.Fprintln(tw, strings.Join(zero.header(wide), "\t"))
+		for _, it := range items {
+			fmt.Fprintln(tw, strings.Join(it.row(wide), "\t"))
+		}
+		return tw.Flush()
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		var zero T
+		cw.Write(zero.header(true))
+		for _, it := range items {
+			cw.Write(it.row(true))
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(document(kind, items))
+
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(document(kind, items)); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unknown format %q, use table, wide, json, yaml, csv or a Go template", format)
+}
+
+func document[T any](kind string, items []T) Document[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Document[T]{Version: Version, Kind: kind, Items: items}
+}
+
+func writeTemplate[T any](w io.Writer, text string, items []T) error {
+	tmpl, err := template.New("format").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"join": strings.Join,
+	}).Parse(text)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		if err := tmpl.Execute(w, it); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}